@@ -9,28 +9,27 @@ import (
 	_ "embed"
 	"errors"
 	"fmt"
+	"io"
+	neturl "net/url"
 	"os"
-	"sort"
-	"strconv"
+	"os/signal"
 	"strings"
-	"sync"
-	"time"
+	"syscall"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/aws/middleware"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
-	"github.com/dustin/go-humanize"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
 const (
-	// Per AWS API Valid Range: Minimum value of 1. Maximum value of 10000.
-	maxItems   int32  = 10000
 	regionFile string = "aws-regions.txt"
 )
 
@@ -48,553 +47,489 @@ var cleanCmd = &cobra.Command{
 	Short: "Removes all former versions of AWS lambdas except for the $LATEST version",
 	Long:  `Removes all former versions of AWS lambdas except for the $LATEST version. The user also has the ability specify n-? version to retain.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ctx := context.Background()
-
-		var (
-			awsEnvRegion      string
-			awsEnvProfile     string
-			config            cliConfig
-			err               error
-			customeDeleteList []string
-		)
-
-		config = GlobalCliConfig
-		awsEnvRegion = os.Getenv("AWS_DEFAULT_REGION")
-		awsEnvProfile = os.Getenv("AWS_PROFILE")
-
-		if *config.RegionFlag == "" {
-			if awsEnvRegion != "" {
-				*config.RegionFlag, err = validateRegion(f, awsEnvRegion)
-				if err != nil {
-					return err
-				}
-			} else {
-				return errors.New("missing region flag and AWS_DEFAULT_REGION env variable. Please use -r and provide a valid AWS region")
-			}
-		} else {
-			*config.RegionFlag, err = validateRegion(f, *config.RegionFlag)
-			if err != nil {
-				return err
-			}
-		}
-
-		// Create a list of AWS Configurations Options
-		awsConfigOptions := []func(*awsConfig.LoadOptions) error{
-			awsConfig.WithRegion(*config.RegionFlag),
-			awsConfig.WithHTTPClient(GlobalHTTPClient),
-			awsConfig.WithAssumeRoleCredentialOptions(func(aro *stscreds.AssumeRoleOptions) {
-				aro.TokenProvider = stscreds.StdinTokenProvider
-			}),
-		}
-		if *config.ProfileFlag == "" {
-			if awsEnvProfile != "" {
-				log.Infof("AWS_PROFILE set to \"%s\"", awsEnvProfile)
-				config.ProfileFlag = &awsEnvProfile
-			}
-		} else {
-			log.Infof("The AWS Profile flag \"%s\" was passed in", *config.ProfileFlag)
-		}
-
-		awsConfigOptions = append(awsConfigOptions, awsConfig.WithSharedConfigProfile(*config.ProfileFlag))
-
-		if *config.Verbose {
-			awsConfigOptions = append(awsConfigOptions, awsConfig.WithClientLogMode(aws.LogRetries|aws.LogRequest))
-		}
-
-		if *config.DryRun {
-			log.Info("******** DRY RUN MODE ENABLED ********")
-		}
+		ctx, cancel := interruptibleContext(context.Background())
+		defer cancel()
 
+		config := GlobalCliConfig
 		config.SkipAliases = &SkipAliases
+		config.Verify = Verify
+		config.Concurrency = Concurrency
+		config.RateLimit = RateLimit
+		config.StateFile = StateFile
+		config.ResumeFile = ResumeFile
+		config.EndpointURL = EndpointURL
+		config.ExcludeFile = ExcludeFile
+		config.ListColumn = ListColumn
+		config.KeepDescription = KeepDescription
+		config.ProtectCfn = ProtectCfn
+		config.ProtectSfn = ProtectSfn
+		config.ProtectIntegrations = ProtectIntegrations
+		config.ProtectCodeDeploy = ProtectCodeDeploy
+		config.TagOverrides = TagOverrides
+		config.StackNames = StackNames
+		config.NestedStacks = NestedStacks
+		config.RetainBehindAlias = RetainBehindAlias
+		config.PruneAliases = PruneAliases
+		config.SkipUpdating = SkipUpdating
+
+		if UpdateGracePeriod != "" {
+			updateGracePeriod, err := internal.ParseOlderThan(UpdateGracePeriod)
+			if err != nil {
+				return fmt.Errorf("invalid --update-grace-period value: %w", err)
+			}
 
-		if *config.SkipAliases {
-			log.Info("Skip Aliases enabled")
+			config.UpdateGracePeriod = updateGracePeriod
 		}
 
-		if *config.LambdaListFile != "" {
-			log.Info("******** CUSTOM LAMBDA LIST PROVIDED ********")
-
-			list, err := internal.GenerateLambdaDeleteList(*config.LambdaListFile)
+		if AliasOlderThan != "" {
+			aliasOlderThan, err := internal.ParseOlderThan(AliasOlderThan)
 			if err != nil {
-				log.Infof("an issue occurred while processing %s", *config.LambdaListFile)
-				log.Info(err.Error())
+				return fmt.Errorf("invalid --alias-older-than value: %w", err)
 			}
 
-			customeDeleteList = list
+			config.AliasOlderThan = aliasOlderThan
 		}
 
-		cfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfigOptions...)
+		runs, err := planTargetRuns(config, Target, AllTargets, effectiveConfig)
 		if err != nil {
-			return errors.New("ERROR ESTABLISHING AWS SESSION")
+			return err
 		}
 
-		creds, err := cfg.Credentials.Retrieve(ctx)
-		if err != nil {
-			return errors.New("ERROR RETRIEVING AWS CREDENTIALS")
-		}
+		// The standard input can only be read once, so the list is read before the runs and shared by each of them.
+		if *config.LambdaListFile == internal.StdinSource && len(runs) > 1 {
+			list, err := readCustomList(ctx, config, os.Stdin, nil)
+			if err != nil {
+				return err
+			}
 
-		if creds.Expired() {
-			return errors.New("AWS CREDENTIALS EXPIRED")
+			for i := range runs {
+				runs[i].config.CustomList = list
+			}
 		}
 
-		// svc = lambda.NewFromConfig(cfg)
-		initSvc := lambda.NewFromConfig(cfg, func(o *lambda.Options) {
-			// Set the User-Agent for all AWS with the Lambda client
-			o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
-		})
+		if len(runs) == 1 && runs[0].target == "" {
+			_, err = runClean(ctx, runs[0].config)
 
-		err = executeClean(ctx, &config, initSvc, customeDeleteList)
-		if err != nil {
 			return err
 		}
 
-		return err
+		return runTargets(ctx, runs)
 	},
 }
 
-/*
-executeClean is the main function that executes the clean-up process
-It takes a context, a pointer to a cliConfig struct, a pointer to a lambda client, and a list of custom lambdas to delete
-An error is returned if the function fails to execute.
-*/
-func executeClean(ctx context.Context, config *cliConfig, svc *lambda.Client, customList []string) error {
-	startTime := time.Now()
-
+// runClean executes the clean-up for a single region and AWS profile. The function validates the configuration, creates the Lambda API client, and returns the totals of the clean-up.
+func runClean(ctx context.Context, config cliConfig) (cleaner.Summary, error) {
 	var (
-		returnError                error
-		globalLambdaStorage        []int64
-		updatedGlobalLambdaStorage []int64
-		globalLambdaVersionsList   [][]types.FunctionConfiguration
-		counter                    int64 = 0
+		err               error
+		customeDeleteList []internal.LambdaEntry
 	)
 
-	log.Info("Scanning AWS environment in " + *config.RegionFlag)
-
-	lambdaList, err := getAllLambdas(ctx, svc, customList)
-	if err != nil {
-		log.Error("ERROR: ", err)
-		log.Fatal("ERROR: Failed to retrieve Lambda list.")
+	if *config.DryRun {
+		log.Info("******** DRY RUN MODE ENABLED ********")
 	}
 
-	log.Info("............")
+	if (config.StateFile != "" || config.ResumeFile != "") && *config.DryRun {
+		return cleaner.Summary{}, errors.New("the --state-file and --resume flags cannot be used with a dry run")
+	}
 
-	if len(lambdaList) > 0 {
-		tempCounter := 0
+	if config.StateFile != "" && config.ResumeFile != "" && config.StateFile != config.ResumeFile {
+		return cleaner.Summary{}, errors.New("the --state-file and --resume flags must point to the same file. The progress of a resumed clean-up is appended to the resumed state file")
+	}
 
-		for _, lambda := range lambdaList {
-			lambdaItem := lambda
+	if len(config.StackNames) > 0 && *config.LambdaListFile != "" {
+		return cleaner.Summary{}, errors.New("the --stack-name and --listFile flags cannot be used together")
+	}
 
-			lambdaVersionsList, err := getAllLambdaVersion(ctx, svc, lambdaItem, *config)
-			if err != nil {
-				log.Error("ERROR: ", err)
-				log.Fatal("ERROR: Failed to retrieve Lambda version list.")
-			}
+	if config.NestedStacks && len(config.StackNames) == 0 {
+		return cleaner.Summary{}, errors.New("the --nested-stacks flag requires the --stack-name flag")
+	}
 
-			globalLambdaVersionsList = append(globalLambdaVersionsList, lambdaVersionsList)
+	if config.AliasOlderThan > 0 && len(config.PruneAliases) == 0 {
+		return cleaner.Summary{}, errors.New("the --alias-older-than flag requires the --prune-aliases flag")
+	}
 
-			totalLambdaStorage, err := getLambdaStorage(lambdaVersionsList)
-			if err != nil {
-				log.Error("ERROR: ", err)
-				log.Fatal("ERROR: Failed to retrieve Lambda storage.")
-			}
+	if config.RetainBehindAlias < 0 {
+		return cleaner.Summary{}, errors.New("the --retain-behind-alias flag must be 0 or greater")
+	}
 
-			globalLambdaStorage = append(globalLambdaStorage, totalLambdaStorage)
-			tempCounter++
+	if config.EndpointURL != "" {
+		// The endpoint is validated before any client is created, as every AWS API client uses it.
+		if _, err := resolveEndpointURL(config.EndpointURL); err != nil {
+			return cleaner.Summary{}, err
 		}
+	}
 
-		log.Info(tempCounter, " Lambdas identified")
+	if *config.SkipAliases {
+		log.Info("Skip Aliases enabled")
+	}
 
-		for _, v := range globalLambdaStorage {
-			counter = counter + v
+	if config.ExcludeFile != "" {
+		// An exclude file that cannot be processed stops the clean-up so that no protected Lambda is cleaned.
+		config.Protected, err = readExcludeFile(config.ExcludeFile)
+		if err != nil {
+			return cleaner.Summary{}, err
 		}
 
-		log.Info("Current storage size: ", calculateFileSize(uint64(counter), config))
-		log.Info("**************************")
-		log.Info("Initiating clean-up process. This may take a few minutes....")
-		// Begin delete process
-		globalLambdaDeleteList := [][]types.FunctionConfiguration{}
+		log.Infof("%d Lambdas protected by %s", len(config.Protected), config.ExcludeFile)
+	}
 
-		for _, lambda := range globalLambdaVersionsList {
-			lambdasDeleteList := getLambdasToDeleteList(lambda, *config.Retain)
-			globalLambdaDeleteList = append(globalLambdaDeleteList, lambdasDeleteList)
+	cfg, err := newAWSConfig(ctx, &config)
+	if err != nil {
+		return cleaner.Summary{}, err
+	}
+
+	if config.ProtectCfn {
+		// A stack scan that fails stops the clean-up so that no version referenced by a stack is deleted.
+		config.ProtectedVersions, err = stackLambdaVersions(ctx, newCloudFormationClient(cfg, config.EndpointURL))
+		if err != nil {
+			return cleaner.Summary{}, err
 		}
 
-		log.Info("............")
+		log.Infof("%d Lambda versions protected by CloudFormation stacks", len(config.ProtectedVersions))
+	}
 
-		globalLambdaDeleteInputStructs, err := generateDeleteInputStructs(globalLambdaDeleteList, *config.MoreLambdaDetails)
+	if config.ProtectSfn {
+		// A state machine scan that fails stops the clean-up so that no version referenced by a workflow is deleted.
+		versions, err := stateMachineLambdaVersions(ctx, newStepFunctionsClient(cfg, config.EndpointURL))
 		if err != nil {
-			log.Error("ERROR: ", err)
-			log.Fatal("ERROR: Failed to generate delete input structs")
+			return cleaner.Summary{}, err
 		}
 
-		log.Info("............")
+		config.ProtectedVersions = append(config.ProtectedVersions, versions...)
 
-		if *config.DryRun {
-			numVerDeleted := countDeleteVersions(globalLambdaDeleteInputStructs)
-			log.Info(fmt.Sprintf("%d unique versions will be removed in an actual execution.", numVerDeleted))
+		log.Infof("%d Lambda versions protected by Step Functions state machines", len(versions))
+	}
 
-			spaceRemovedPreview := calculateSpaceRemoval(globalLambdaDeleteList)
-			log.Info(calculateFileSize(uint64(spaceRemovedPreview), config) + " of storage space will be removed in an actual execution.")
+	if config.ProtectCodeDeploy {
+		// A deployment scan that fails stops the clean-up so that no version of a traffic shift in progress is deleted.
+		versions, err := codeDeployLambdaVersions(ctx, newCodeDeployClient(cfg, config.EndpointURL), newS3Client(cfg, config.EndpointURL))
+		if err != nil {
+			return cleaner.Summary{}, err
+		}
 
-			displayDuration(startTime)
+		config.ProtectedVersions = append(config.ProtectedVersions, versions...)
 
-			return returnError
-		}
+		log.Infof("%d Lambda versions protected by CodeDeploy deployments in progress", len(versions))
+	}
 
-		err = deleteLambdaVersion(ctx, svc, globalLambdaDeleteInputStructs...)
+	if len(config.StackNames) > 0 {
+		// A stack without Lambdas stops the clean-up rather than falling back to a full scan of the region.
+		functions, err := stackLambdaFunctions(ctx, newCloudFormationClient(cfg, config.EndpointURL), config.StackNames, config.NestedStacks)
 		if err != nil {
-			log.Error("ERROR: ", err)
-			log.Fatal("ERROR: Failed to delete Lambda versions.")
+			return cleaner.Summary{}, err
 		}
 
-		// Recalculate storage size
-		updatedLambdaList, err := getAllLambdas(ctx, svc, customList)
-		if err != nil {
-			log.Error("ERROR: ", err)
-			log.Fatal("ERROR: Failed to retrieve Lambda list.")
+		if len(functions) == 0 {
+			return cleaner.Summary{}, errors.New("the CloudFormation stacks " + strings.Join(config.StackNames, ", ") + " do not contain any Lambda")
 		}
 
-		log.Info("............")
+		log.Infof("%d Lambdas found in the CloudFormation stacks %s", len(functions), strings.Join(config.StackNames, ", "))
 
-		for _, lambda := range updatedLambdaList {
-			updatededlambdaVersionsList, err := getAllLambdaVersion(ctx, svc, lambda, *config)
-			if err != nil {
-				log.Error("ERROR: ", err)
-				log.Fatal("ERROR: Failed to retrieve Lambda version list.")
-			}
-
-			updatedTotalLambdaStorage, err := getLambdaStorage(updatededlambdaVersionsList)
-			if err != nil {
-				log.Error("ERROR: ", err)
-				log.Fatal("ERROR: Failed to retrieve Lambda storage size.")
-			}
-
-			updatedGlobalLambdaStorage = append(updatedGlobalLambdaStorage, updatedTotalLambdaStorage)
+		for _, name := range functions {
+			customeDeleteList = append(customeDeleteList, internal.LambdaEntry{Name: name})
 		}
+	}
 
-		log.Info("............")
-
-		var updatedCounter int64 = 0
-		for _, v := range updatedGlobalLambdaStorage {
-			updatedCounter = updatedCounter + v
-		}
+	if *config.LambdaListFile != "" {
+		log.Info("******** CUSTOM LAMBDA LIST PROVIDED ********")
 
-		if len(lambdaList) == 0 {
-			log.Info("No lambdas found in ", *config.RegionFlag)
-		} else {
-			log.Info("Total versions removed: ", countDeleteVersions(globalLambdaDeleteInputStructs))
-			log.Info("Total space freed up: ", (calculateFileSize(uint64(counter-updatedCounter), config)))
-			log.Info("Post clean-up storage size: ", calculateFileSize(uint64(updatedCounter), config))
-			log.Info("*********************************************")
+		customeDeleteList = config.CustomList
+		if customeDeleteList == nil {
+			customeDeleteList, err = readCustomList(ctx, config, os.Stdin, newS3Client(cfg, config.EndpointURL))
+			if err != nil {
+				return cleaner.Summary{}, err
+			}
 		}
 	}
 
-	displayDuration(startTime)
+	initSvc, err := newLambdaClient(cfg, config)
+	if err != nil {
+		return cleaner.Summary{}, err
+	}
 
-	return returnError
+	return executeClean(ctx, &config, initSvc, customeDeleteList)
 }
 
-// displayDuration calculates the duration based on a provided start time.
-func displayDuration(startTime time.Time) {
-	var (
-		elapsedTime float64
-		timeUnit    string
-	)
-
-	t1 := time.Now()
+// readCustomList reads the custom list of the configuration from a file, the standard input, or a URL.
+// A list that cannot be read, contains an invalid entry, or is empty stops the clean-up rather than falling back to a full scan of the region.
+func readCustomList(ctx context.Context, config cliConfig, stdin io.Reader, s3svc internal.S3GetObjectAPI) ([]internal.LambdaEntry, error) {
+	list, err := internal.ReadLambdaList(ctx, *config.LambdaListFile, internal.ListSourceOptions{
+		Column:     config.ListColumn,
+		Stdin:      stdin,
+		HTTPClient: GlobalHTTPClient,
+		S3:         s3svc,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to process the custom list %s: %w", *config.LambdaListFile, err)
+	}
 
-	tempTime := t1.Sub(startTime)
-	if tempTime.Minutes() > 1 {
-		elapsedTime = tempTime.Minutes()
-		timeUnit = "m"
-	} else {
-		elapsedTime = tempTime.Seconds()
-		timeUnit = "s"
+	if len(list) == 0 {
+		return nil, errors.New("the custom list " + *config.LambdaListFile + " does not contain any Lambda")
 	}
 
-	log.Infof("Job Duration Time: %f%s", elapsedTime, timeUnit)
+	return list, nil
 }
 
-// generateDeleteInputStructs takes a list of lambda.DeleteFunctionInput and a boolean value to determine if the user wants more details. The function returns a list of lambda.DeleteFunctionInput
-// An error is returned if the function fails to execute.
-func generateDeleteInputStructs(versionsList [][]types.FunctionConfiguration, details bool) ([][]lambda.DeleteFunctionInput, error) {
+// newAWSConfig returns the AWS configuration for the region and the AWS profile of the configuration.
+// The region is validated and updated in place. The AWS credentials are retrieved to ensure they are valid before the configuration is returned.
+func newAWSConfig(ctx context.Context, config *cliConfig) (aws.Config, error) {
 	var (
-		returnError error
-		output      [][]lambda.DeleteFunctionInput
+		awsEnvRegion  string
+		awsEnvProfile string
+		err           error
 	)
 
-	for _, version := range versionsList {
-		var tempList []lambda.DeleteFunctionInput
-
-		var functionName string
-
-		for _, entry := range version {
-			if *entry.Version != "$LATEST" {
-				if functionName == "" {
-					functionName = *entry.FunctionName
-				}
+	awsEnvRegion = os.Getenv("AWS_DEFAULT_REGION")
+	awsEnvProfile = os.Getenv("AWS_PROFILE")
 
-				deleteItem := &lambda.DeleteFunctionInput{
-					FunctionName: entry.FunctionName,
-					Qualifier:    entry.Version,
-				}
-
-				tempList = append(tempList, *deleteItem)
+	if *config.RegionFlag == "" {
+		if awsEnvRegion != "" {
+			*config.RegionFlag, err = validateRegion(f, awsEnvRegion)
+			if err != nil {
+				return aws.Config{}, err
 			}
+		} else {
+			return aws.Config{}, errors.New("missing region flag and AWS_DEFAULT_REGION env variable. Please use -r and provide a valid AWS region")
 		}
-
-		if details && functionName != "" {
-			log.Info(fmt.Sprintf("%5d versions of %s to be removed", len(tempList), functionName))
+	} else {
+		*config.RegionFlag, err = validateRegion(f, *config.RegionFlag)
+		if err != nil {
+			return aws.Config{}, err
 		}
-
-		output = append(output, tempList)
 	}
 
-	return output, returnError
-}
-
-// calculateSpaceRemoval returns the total size of all the versions to be deleted.
-// The function takes a list of lambda.DeleteFunctionInput and returns an int.
-func calculateSpaceRemoval(deleteList [][]types.FunctionConfiguration) int {
-	var (
-		size int
-	)
-
-	for _, lambda := range deleteList {
-		for _, version := range lambda {
-			if *version.Version != "$LATEST" {
-				size = size + int(version.CodeSize)
-			}
+	// Create a list of AWS Configurations Options
+	awsConfigOptions := []func(*awsConfig.LoadOptions) error{
+		awsConfig.WithRegion(*config.RegionFlag),
+		awsConfig.WithHTTPClient(GlobalHTTPClient),
+		awsConfig.WithAssumeRoleCredentialOptions(func(aro *stscreds.AssumeRoleOptions) {
+			aro.TokenProvider = stscreds.StdinTokenProvider
+		}),
+	}
+	if *config.ProfileFlag == "" {
+		if awsEnvProfile != "" {
+			log.Infof("AWS_PROFILE set to \"%s\"", awsEnvProfile)
+			config.ProfileFlag = &awsEnvProfile
 		}
+	} else {
+		log.Infof("The AWS Profile flag \"%s\" was passed in", *config.ProfileFlag)
 	}
 
-	return size
-}
+	awsConfigOptions = append(awsConfigOptions, awsConfig.WithSharedConfigProfile(*config.ProfileFlag))
 
-// countDeleteVersions returns the total number of versions to be deleted.
-// The function takes a list of lambda.DeleteFunctionInput and returns an int.
-func countDeleteVersions(deleteList [][]lambda.DeleteFunctionInput) int {
-	var (
-		versionsCount int
-	)
+	if *config.Verbose {
+		awsConfigOptions = append(awsConfigOptions, awsConfig.WithClientLogMode(aws.LogRetries|aws.LogRequest))
+	}
 
-	for _, lambda := range deleteList {
-		versionsCount = versionsCount + len(lambda)
+	cfg, err := awsConfig.LoadDefaultConfig(ctx, awsConfigOptions...)
+	if err != nil {
+		return aws.Config{}, errors.New("ERROR ESTABLISHING AWS SESSION")
 	}
 
-	return versionsCount
-}
+	if config.RoleARN != "" {
+		log.Infof("Assuming the role \"%s\"", config.RoleARN)
 
-// deleteLambdaVersion takes a list of lambda.DeleteFunctionInput and deletes all the versions in the list
-// The function takes a context, a pointer to a lambda client, and a list of lambda.DeleteFunctionInput. A variadic operator is used to allow the user to pass in multiple lists of lambda.DeleteFunctionInput
-// Use this function with caution as it will delete all the versions in the list.
-func deleteLambdaVersion(ctx context.Context, svc *lambda.Client, deleteList ...[]lambda.DeleteFunctionInput) error {
-	var (
-		returnError error
-		wg          sync.WaitGroup
-	)
+		cfg.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), config.RoleARN))
+	}
 
-	for _, versions := range deleteList {
-		for _, version := range versions {
-			wg.Add(1)
-			func() {
-				defer wg.Done()
-
-				_, err := svc.DeleteFunction(ctx, &version)
-				if err != nil {
-					err = errors.New("Failed to delete version " + *version.Qualifier + " of " + *version.FunctionName + ". \n Additional details: " + err.Error())
-					returnError = err
-				}
-			}()
-		}
+	creds, err := cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return aws.Config{}, errors.New("ERROR RETRIEVING AWS CREDENTIALS")
 	}
 
-	wg.Wait()
+	if creds.Expired() {
+		return aws.Config{}, errors.New("AWS CREDENTIALS EXPIRED")
+	}
 
-	return returnError
+	return cfg, nil
 }
 
-// getLambdasToDeleteList takes a list of lambda.FunctionConfiguration and a int8 value to determine how many versions to retain. The function returns a list of lambda.FunctionConfiguration.
-func getLambdasToDeleteList(list []types.FunctionConfiguration, retainCount int8) []types.FunctionConfiguration {
-	var retainNumber int
-	// Ensure the passed in parameter is greater than zero
-	if retainCount >= 1 {
-		retainNumber = int(retainCount)
+// newLambdaClient returns a Lambda API client that uses the AWS configuration and the custom endpoint URL of the configuration, if any.
+func newLambdaClient(cfg aws.Config, config cliConfig) (*lambda.Client, error) {
+	endpointURL, err := resolveEndpointURL(config.EndpointURL)
+	if err != nil {
+		return nil, err
 	}
 
-	// If passed in parameter is less than zero than set the default value to 0
-	if retainCount < 1 {
-		retainNumber = 1
+	if endpointURL != "" {
+		log.Infof("Custom endpoint URL \"%s\" set", endpointURL)
 	}
 
-	// This checks to ensure that we are not deleting a list that only contains $LATEST
-	if (len(list)) > 1 && (int(retainNumber) < len(list)) {
-		return list[retainNumber:]
-	} else {
-		return nil
-	}
+	// svc = lambda.NewFromConfig(cfg)
+	return lambda.NewFromConfig(cfg, func(o *lambda.Options) {
+		// Set the User-Agent for all AWS with the Lambda client
+		o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	}), nil
 }
 
-// getAllLambdas returns a list of all available lambdas in the AWS environment. The function takes a context, a pointer to a lambda client, and a list of custom lambdas function names to delete.
-func getAllLambdas(ctx context.Context, svc *lambda.Client, customList []string) ([]types.FunctionConfiguration, error) {
-	var (
-		lambdasListOutput []types.FunctionConfiguration
-		returnError       error
-		input             *lambda.ListFunctionsInput
-	)
-
-	if len(customList) == 0 {
-		input = &lambda.ListFunctionsInput{
-			MaxItems: aws.Int32(maxItems),
+// newS3Client returns an Amazon S3 API client that reads the custom lists and the AppSpec files of the deployments.
+// The endpointURL of the --endpoint-url flag takes precedence and is addressed with path-style URLs, as emulators such as LocalStack expect. Otherwise, the endpoint is resolved by the AWS SDK.
+func newS3Client(cfg aws.Config, endpointURL string) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+			o.UsePathStyle = true
 		}
+	})
+}
 
-		p := lambda.NewListFunctionsPaginator(svc, input)
-		for p.HasMorePages() {
-			page, err := p.NextPage(ctx)
-			if err != nil {
-				log.Error(err)
-
-				return lambdasListOutput, err
-			}
+/*
+executeClean is the main function that executes the clean-up process
+It takes a context, a pointer to a cliConfig struct, a Lambda API client, and the entries of a custom list of lambdas to delete
+The settings of each entry take precedence over the CLI configuration for that lambda. Entries limited to another region are skipped.
+The clean-up is delegated to the cleaner package. The totals of the clean-up are returned along with an error if the function fails to execute.
+*/
+func executeClean(ctx context.Context, config *cliConfig, svc cleaner.LambdaAPI, customList []internal.LambdaEntry) (cleaner.Summary, error) {
+	opts := newCleanerOptions(*config, customList)
+	if len(customList) > 0 && len(opts.Functions) == 0 {
+		log.Info("No lambdas of the custom list apply to ", opts.Region)
 
-			lambdasListOutput = append(lambdasListOutput, page.Functions...)
-		}
+		return cleaner.Summary{}, nil
 	}
 
-	if len(customList) > 0 {
-		for _, item := range customList {
-			input := &lambda.GetFunctionInput{
-				FunctionName: aws.String(item),
-			}
+	summary, err := cleaner.New(svc, opts).Run(ctx)
+	if errors.Is(err, cleaner.ErrInterrupted) {
+		return summary, newInterruptedError()
+	}
 
-			result, err := svc.GetFunction(ctx, input)
-			if err != nil {
-				var rnf *types.ResourceNotFoundException
-				if errors.As(err, &rnf) {
-					log.Warn(fmt.Sprintf("The lambda function %s does not exist. Ensure you specified the correct name and that function exists and try again. ", item))
-					log.Warn("Skipping " + item)
+	return summary, err
+}
 
-					continue
-				}
+// newCleanerOptions returns the cleaner options matching the CLI configuration and the entries of the custom list.
+func newCleanerOptions(config cliConfig, customList []internal.LambdaEntry) cleaner.Options {
+	functions, overrides := customListOptions(customList, *config.RegionFlag)
+
+	return cleaner.Options{
+		Region:              *config.RegionFlag,
+		Retain:              *config.Retain,
+		DryRun:              *config.DryRun,
+		SkipAliases:         *config.SkipAliases,
+		RetainBehindAlias:   config.RetainBehindAlias,
+		PruneAliases:        config.PruneAliases,
+		AliasOlderThan:      config.AliasOlderThan,
+		SkipUpdating:        config.SkipUpdating,
+		UpdateGracePeriod:   config.UpdateGracePeriod,
+		MoreLambdaDetails:   *config.MoreLambdaDetails,
+		SizeIEC:             *config.SizeIEC,
+		Verify:              config.Verify,
+		Concurrency:         config.Concurrency,
+		RateLimit:           config.RateLimit,
+		StateFile:           config.StateFile,
+		ResumeFile:          config.ResumeFile,
+		Functions:           functions,
+		Include:             config.Include,
+		Exclude:             config.Exclude,
+		Overrides:           overrides,
+		Protected:           config.Protected,
+		KeepDescription:     config.KeepDescription,
+		OlderThan:           config.OlderThan,
+		ProtectedVersions:   config.ProtectedVersions,
+		ProtectIntegrations: config.ProtectIntegrations,
+		TagOverrides:        config.TagOverrides,
+	}
+}
 
-				returnError = err
-			}
+// readExcludeFile returns the names of the Lambdas listed in an exclude file. The file uses the same formats as the custom list.
+// The Lambdas are protected in every region and the per-Lambda settings of the entries are ignored.
+func readExcludeFile(file string) ([]string, error) {
+	entries, err := internal.GenerateLambdaDeleteEntries(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to process the exclude file %s: %w", file, err)
+	}
 
-			if result != nil && result.Configuration != nil {
-				lambdasListOutput = append(lambdasListOutput, *result.Configuration)
-			}
-		}
+	protected := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		protected = append(protected, entry.FunctionName())
 	}
 
-	return lambdasListOutput, returnError
+	return protected, nil
 }
 
-// getAllLambdaVersion returns a list of all available versions for a given lambda. The function takes a context, a pointer to a lambda client, and a lambda.FunctionConfiguration.
-func getAllLambdaVersion(
-	ctx context.Context,
-	svc *lambda.Client,
-	item types.FunctionConfiguration,
-	flags cliConfig,
-) ([]types.FunctionConfiguration, error) {
+// customListOptions returns the names and the overrides of the custom list entries that apply to the region.
+func customListOptions(customList []internal.LambdaEntry, region string) ([]string, map[string]cleaner.Override) {
 	var (
-		lambdasLisOutput []types.FunctionConfiguration
-		returnError      error
-		input            *lambda.ListVersionsByFunctionInput
+		functions []string
+		overrides map[string]cleaner.Override
 	)
 
-	input = &lambda.ListVersionsByFunctionInput{
-		FunctionName: aws.String(*item.FunctionName),
-		MaxItems:     aws.Int32(maxItems),
-	}
-
-	p := lambda.NewListVersionsByFunctionPaginator(svc, input)
-	for p.HasMorePages() {
-		page, err := p.NextPage(ctx)
-		if err != nil {
-			log.Error(err)
+	for _, entry := range customList {
+		if entryRegion := entry.FunctionRegion(); entryRegion != "" && entryRegion != region {
+			log.Infof("Skipping %s as it is limited to %s", entry.Name, entryRegion)
 
-			return lambdasLisOutput, err
+			continue
 		}
 
-		lambdasLisOutput = append(lambdasLisOutput, page.Versions...)
-	}
-
-	if *flags.SkipAliases {
-		// fetch the list of aliases for this function
-		pg := lambda.NewListAliasesPaginator(svc, &lambda.ListAliasesInput{
-			FunctionName: aws.String(*item.FunctionArn),
-			MaxItems:     aws.Int32(maxItems),
-		})
-
-		var aliasesOut []types.AliasConfiguration
-
-		for pg.HasMorePages() {
-			page, err := pg.NextPage(ctx)
-			if err != nil {
-				log.Error(err)
-
-				return lambdasLisOutput, err
-			}
+		functions = append(functions, entry.Name)
 
-			aliasesOut = append(aliasesOut, page.Aliases...)
+		if !entry.HasOverrides() {
+			continue
 		}
 
-		log.Debug(fmt.Sprintf("Lamba function %s has %d aliases \n", *item.FunctionName, len(aliasesOut)))
+		// The entries are validated when the custom list is read.
+		olderThan, _ := internal.ParseOlderThan(entry.OlderThan)
 
-		// produce a new slice that includes only versions for which there is no alias
-		var result []types.FunctionConfiguration
-
-		for _, funConf := range lambdasLisOutput {
-			isAlias := false
-
-			for _, alias := range aliasesOut {
-				if alias.FunctionVersion != nil && *alias.FunctionVersion == *funConf.Version {
-					isAlias = true
-
-					break
-				}
-			}
-
-			if !isAlias {
-				result = append(result, funConf)
-			}
+		if overrides == nil {
+			overrides = make(map[string]cleaner.Override)
 		}
 
-		// return the pared down list of versions
-		lambdasLisOutput = result
+		overrides[entry.FunctionName()] = cleaner.Override{
+			Retain:      entry.Retain,
+			SkipAliases: entry.SkipAliases,
+			OlderThan:   olderThan,
+		}
 	}
 
-	// Sort list so that the former versions are listed first and $LATEST is listed last
-	sort.Sort(byVersion(lambdasLisOutput))
-
-	return lambdasLisOutput, returnError
+	return functions, overrides
 }
 
-type byVersion []types.FunctionConfiguration
+// interruptibleContext returns a context that is cancelled when a SIGINT or SIGTERM signal is received.
+// Once the context is cancelled, the signal handler is removed so that a second signal terminates the process immediately.
+func interruptibleContext(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
 
-func (a byVersion) Len() int { return len(a) }
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
 
-func (a byVersion) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+	go func() {
+		defer signal.Stop(signals)
 
-func (a byVersion) Less(i, j int) bool {
-	one, _ := strconv.ParseInt(*a[i].Version, 10, 32)
-	two, _ := strconv.ParseInt(*a[j].Version, 10, 32)
+		select {
+		case sig := <-signals:
+			log.Warnf("%s received. Waiting for in-flight deletions to complete. Send the signal again to exit immediately.", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
 
-	return one > two
+	return ctx, cancel
 }
 
-// getLambdaStorage calculates the aggregate sum of all the functions' size.
-func getLambdaStorage(list []types.FunctionConfiguration) (int64, error) {
-	var (
-		sizeCounter int64
-		returnError error
-	)
+// resolveEndpointURL returns the endpoint URL to use for the AWS Lambda API. The flag value takes precedence over the AWS_ENDPOINT_URL_LAMBDA and AWS_ENDPOINT_URL env variables.
+// The env variables are ignored if AWS_IGNORE_CONFIGURED_ENDPOINT_URLS is set to true. An empty string is returned if no custom endpoint URL is configured.
+func resolveEndpointURL(flag string) (string, error) {
+	endpoint := flag
+
+	if endpoint == "" && !strings.EqualFold(os.Getenv("AWS_IGNORE_CONFIGURED_ENDPOINT_URLS"), "true") {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL_LAMBDA")
+		if endpoint == "" {
+			endpoint = os.Getenv("AWS_ENDPOINT_URL")
+		}
+	}
+
+	if endpoint == "" {
+		return "", nil
+	}
 
-	for _, item := range list {
-		sizeCounter = sizeCounter + item.CodeSize
+	u, err := neturl.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errors.New(endpoint + " is an invalid endpoint URL. Please provide an absolute URL such as http://localhost:4566")
 	}
 
-	return sizeCounter, returnError
+	return endpoint, nil
 }
 
 // validateRegion validates the user input to ensure it is a valid AWS region. The function takes a embed.FS and a string. The function returns a string and an error
@@ -622,12 +557,3 @@ func validateRegion(f embed.FS, input string) (string, error) {
 
 	return output, err
 }
-
-// calculateFileSize returns the size of a file in bytes. The function takes a cliConfig parameter to determine the number format type to return.
-func calculateFileSize(value uint64, config *cliConfig) string {
-	if *config.SizeIEC {
-		return humanize.IBytes(value)
-	}
-
-	return humanize.Bytes(value)
-}
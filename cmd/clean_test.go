@@ -9,9 +9,13 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
-	"regexp"
-	"sort"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -23,6 +27,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/lambda"
 	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
 	"github.com/docker/go-connections/nat"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner/cleanertest"
 	log "github.com/sirupsen/logrus"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/localstack"
@@ -34,993 +40,6 @@ var (
 	rr embed.FS
 )
 
-func TestGetLambdaStorage(t *testing.T) {
-
-	var (
-		lambdaList []types.FunctionConfiguration
-		want       int64
-	)
-
-	lambdaList = []types.FunctionConfiguration{
-		{
-			CodeSha256:       new(string),
-			CodeSize:         1200,
-			DeadLetterConfig: &types.DeadLetterConfig{},
-			Description:      aws.String("Test A"),
-		},
-		{
-			CodeSha256: new(string),
-			CodeSize:   1500,
-		},
-	}
-
-	want = 2700
-	got, err := getLambdaStorage(lambdaList)
-	if got != want || err != nil {
-		t.Fatalf("Lambda storage calculation invalid. Expected %d but received %d", want, got)
-	}
-}
-
-func TestGetLambdasToDeleteList(t *testing.T) {
-	var (
-		retainNumber int8 = 2
-		lambdaList   []types.FunctionConfiguration
-		want         int = 3
-	)
-
-	lambdaList = []types.FunctionConfiguration{
-		{
-
-			CodeSha256:  new(string),
-			Version:     aws.String("1"),
-			CodeSize:    1200,
-			Description: aws.String("Test A"),
-		},
-		{
-
-			CodeSha256: new(string),
-			Version:    aws.String("2"),
-			CodeSize:   1500,
-		},
-		{
-
-			CodeSha256: new(string),
-			Version:    aws.String("3"),
-			CodeSize:   1500,
-		},
-		{
-
-			CodeSha256: new(string),
-			Version:    aws.String("4"),
-			CodeSize:   1500,
-		},
-		{
-
-			CodeSha256: new(string),
-			Version:    aws.String("5"),
-			CodeSize:   1500,
-		},
-	}
-
-	sort.Sort(byVersion(lambdaList))
-
-	got := getLambdasToDeleteList(lambdaList, retainNumber)
-
-	if len(got) != want {
-		t.Fatalf("Expected %d lambda configuration items to be returned but instead received %d", want, len(got))
-	}
-
-}
-
-func TestGenerateDeleteInputStructs(t *testing.T) {
-
-	lambdaList := [][]types.FunctionConfiguration{
-		{
-			types.FunctionConfiguration{
-				CodeSha256:       new(string),
-				FunctionName:     aws.String("A"),
-				Version:          aws.String("1"),
-				CodeSize:         1200,
-				DeadLetterConfig: &types.DeadLetterConfig{},
-				Description:      aws.String("Test A"),
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("B"),
-				Version:      aws.String("2"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("C"),
-				Version:      aws.String("3"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("D"),
-				Version:      aws.String("4"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("E"),
-				Version:      aws.String("5"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("F"),
-				Version:      aws.String("$LATEST"),
-				CodeSize:     1500,
-			},
-		},
-		{
-			types.FunctionConfiguration{
-				CodeSha256:       new(string),
-				Version:          aws.String("1"),
-				FunctionName:     aws.String("A1"),
-				CodeSize:         1200,
-				DeadLetterConfig: &types.DeadLetterConfig{},
-				Description:      aws.String("Test A"),
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("A2"),
-				Version:      aws.String("2"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("A3"),
-				Version:      aws.String("3"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("A4"),
-				Version:      aws.String("$LATEST"),
-				CodeSize:     1500,
-			},
-		},
-	}
-
-	got, err := generateDeleteInputStructs(lambdaList, false)
-	if len(got) != 2 || err != nil {
-		t.Fatalf("Expected a lambda delete struct list to be 2 but go a length of %d", len(got))
-	}
-
-	if (*got[1][1].FunctionName != "A2") || err != nil {
-		t.Fatalf("Expected a lambda delete struct to have item A2 but instead got %v", *got[1][1].FunctionName)
-	}
-
-}
-
-func TestCountDeleteVersions(t *testing.T) {
-
-	lambdaList := [][]lambda.DeleteFunctionInput{
-		{
-			{
-
-				FunctionName: aws.String("A"),
-				Qualifier:    aws.String("1"),
-			},
-			{
-				FunctionName: aws.String("B"),
-				Qualifier:    aws.String("2"),
-			},
-			{
-				FunctionName: aws.String("C"),
-				Qualifier:    aws.String("3"),
-			},
-			{
-				FunctionName: aws.String("D"),
-				Qualifier:    aws.String("4"),
-			},
-			{
-				FunctionName: aws.String("E"),
-				Qualifier:    aws.String("5"),
-			},
-		},
-		{
-			lambda.DeleteFunctionInput{
-				FunctionName: aws.String("A1"),
-				Qualifier:    aws.String("1"),
-			},
-			lambda.DeleteFunctionInput{
-
-				FunctionName: aws.String("A2"),
-				Qualifier:    aws.String("2"),
-			},
-			lambda.DeleteFunctionInput{
-				FunctionName: aws.String("A3"),
-				Qualifier:    aws.String("3"),
-			},
-		},
-	}
-
-	got := countDeleteVersions(lambdaList)
-
-	want := 8
-
-	if got != want {
-		t.Fatalf("Expected count of versions to be %d but received %d instead", want, got)
-	}
-
-}
-
-func TestCalculateSpaceRemoval(t *testing.T) {
-
-	lambdaList := [][]types.FunctionConfiguration{
-		{
-			types.FunctionConfiguration{
-				CodeSha256:       new(string),
-				FunctionName:     aws.String("A"),
-				Version:          aws.String("1"),
-				CodeSize:         1200,
-				DeadLetterConfig: &types.DeadLetterConfig{},
-				Description:      aws.String("Test A"),
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("B"),
-				Version:      aws.String("2"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("C"),
-				Version:      aws.String("3"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("D"),
-				Version:      aws.String("4"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("E"),
-				Version:      aws.String("5"),
-				CodeSize:     1500,
-			},
-		},
-		{
-			types.FunctionConfiguration{
-				CodeSha256:       new(string),
-				Version:          aws.String("1"),
-				FunctionName:     aws.String("A1"),
-				CodeSize:         1200,
-				DeadLetterConfig: &types.DeadLetterConfig{},
-				Description:      aws.String("Test A"),
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("A2"),
-				Version:      aws.String("2"),
-				CodeSize:     1500,
-			},
-			types.FunctionConfiguration{
-				CodeSha256:   new(string),
-				FunctionName: aws.String("A3"),
-				Version:      aws.String("3"),
-				CodeSize:     1500,
-			},
-		},
-	}
-
-	got := calculateSpaceRemoval(lambdaList)
-
-	want := 11400
-
-	if got != want {
-		t.Fatalf("Expected the size of all versions to be %d but received %d instead", want, got)
-	}
-
-}
-
-func TestCalculateFileSize(t *testing.T) {
-
-	cliConfig := cliConfig{
-		SizeIEC: aws.Bool(true),
-	}
-
-	want := "294 MiB"
-	got := calculateFileSize(308000000, &cliConfig)
-
-	if got != want {
-		t.Fatalf("Expected the size output to be %s but received %s instead", want, got)
-	}
-
-	cliConfig.SizeIEC = aws.Bool(false)
-
-	want2 := "308 MB"
-	got2 := calculateFileSize(308000000, &cliConfig)
-	if got2 != want2 {
-		t.Fatalf("Expected the size output to be %s but received %s instead", want2, got2)
-	}
-
-}
-
-func TestDisplayDuration(t *testing.T) {
-	var buf bytes.Buffer
-	log.SetOutput(&buf)
-
-	startTime := time.Now().Add(-time.Second * 30)
-
-	displayDuration(startTime)
-
-	got := buf.String()
-	want := "time=.* level=.* msg=\"Job Duration Time: 30.00"
-	if match, _ := regexp.MatchString(want, got); !match {
-		t.Errorf("displayDuration() = %q, want %q", got, want)
-	}
-	buf.Reset()
-}
-
-func TestDeleteLambdaVersionError(t *testing.T) {
-
-	ctx := context.Background()
-
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	lambdaClient, err := lambdaClient(ctx, localstackContainer)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(false),
-		Retain:            aws.Int8(2),
-	}
-
-	deleteList := []lambda.DeleteFunctionInput{
-		{
-			FunctionName: aws.String("test"),
-			Qualifier:    aws.String("1"),
-		},
-	}
-
-	err = deleteLambdaVersion(ctx, lambdaClient, deleteList)
-	if err == nil {
-		t.Errorf("expected an error to be returned but received %v", err)
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-	})
-
-}
-
-func TestDeleteLambdaVersion(t *testing.T) {
-
-	ctx := context.Background()
-
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(false),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	deleteList := []lambda.DeleteFunctionInput{
-		{
-			FunctionName: aws.String("func1"),
-			Qualifier:    aws.String("2"),
-		},
-	}
-
-	err = deleteLambdaVersion(ctx, svc, deleteList)
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	result, err := listFunctionVersions(ctx, svc, "func1")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-	if result != 2 {
-		t.Errorf("expected 2 functions to be returned but received %v", result)
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-	})
-
-}
-func TestGetAllLambdas(t *testing.T) {
-
-	ctx := context.Background()
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(false),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	lambdaListResult, err := getAllLambdas(ctx, svc, []string{})
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	result, err := listFunctions(ctx, svc)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	if len(lambdaListResult) != result {
-		t.Errorf("expected 3 functions to be returned but received %v", result)
-	}
-
-	lambdaListResult2, err := getAllLambdas(ctx, svc, []string{"func1"})
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	if len(lambdaListResult2) != 1 {
-		t.Errorf("Scenario 2: expected 1 functions to be returned but received %v", len(lambdaListResult2))
-	}
-
-	_, err = getAllLambdas(ctx, svc, []string{"func22"})
-	if err != nil {
-		t.Errorf("expected an error to be returned but received %v", err)
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-
-	})
-
-}
-
-func TestGetAllLambdasAlias(t *testing.T) {
-
-	ctx := context.Background()
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(true),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = publishAlias(ctx, svc, "func1", "DEMO", "2")
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	count, aliases, err := listFunctionAliases(ctx, svc, "func1", "2")
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	if count != 1 {
-		t.Log(aliases)
-		t.Errorf("expected 1 alias to be returned but received %v", count)
-	}
-
-	t.Logf("func1 has the following alias: %v", aliases)
-
-	lambdaListResult, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func1"),
-		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1"),
-	}, GlobalCliConfig)
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	for _, v := range lambdaListResult {
-		t.Log("Name: ", *v.FunctionName, "Version: ", *v.Version)
-	}
-
-	if len(lambdaListResult) != 2 {
-		t.Errorf("expected 2 versions to be returned but received %v", len(lambdaListResult))
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-
-	})
-
-}
-
-func TestGetAllLambdaVersion(t *testing.T) {
-
-	ctx := context.Background()
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(false),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	versions, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func1"),
-	}, GlobalCliConfig)
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	if len(versions) != 3 {
-		t.Errorf("expected 3 versions to be returned but received %v", len(versions))
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(true),
-			Retain:            aws.Int8(0),
-		}
-
-	})
-
-}
-func TestGetAllLambdaVersionWithAliasError(t *testing.T) {
-
-	ctx := context.Background()
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(true),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = publishAlias(ctx, svc, "func1", "DEMO", "2")
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	versions, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func1"),
-		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1"),
-	}, GlobalCliConfig)
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	if len(versions) != 2 {
-		t.Errorf("expected 2 versions to be returned but received %v", len(versions))
-	}
-
-	_, err = getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func22"),
-		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func22"),
-	}, GlobalCliConfig)
-	if err == nil {
-		t.Errorf("expected an error to be returned but received %v", err)
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-
-	})
-
-}
-
-func TestGetAllLambdaVersionWithAlias(t *testing.T) {
-
-	ctx := context.Background()
-	newNetwork, err := network.New(ctx)
-	if err != nil {
-		t.Errorf("failed to create network: %s", err)
-	}
-	localstackContainer, err := localstack.Run(ctx,
-		"localstack/localstack:3.6",
-		testcontainers.WithEnv(map[string]string{
-			"SERVICES": "lambda"}),
-		testcontainers.WithReuseByName(localstackContainerName),
-		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
-	)
-	if err != nil {
-		t.Errorf("failed to start localstack container: %s", err)
-	}
-	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
-
-	svc, err := getAWSCredentials(ctx, localstackContainer)
-	if err != nil {
-		panic(err)
-	}
-	deleteTestFunctions(ctx, svc)
-
-	GlobalCliConfig = cliConfig{
-		RegionFlag:        aws.String("us-east-1"),
-		CredentialsFile:   aws.Bool(false),
-		ProfileFlag:       aws.String(""),
-		DryRun:            aws.Bool(true),
-		Verbose:           aws.Bool(true),
-		LambdaListFile:    aws.String(""),
-		MoreLambdaDetails: aws.Bool(true),
-		SizeIEC:           aws.Bool(false),
-		SkipAliases:       aws.Bool(true),
-		Retain:            aws.Int8(0),
-	}
-
-	bf, err := getZipPackage("../tests/handler.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = addFunctions(ctx, svc, bf)
-	if err != nil {
-		panic(err)
-	}
-
-	bf2, err := getZipPackage("../tests/handler2.zip")
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = updateFunctions(ctx, svc, *bf2)
-	if err != nil {
-		t.Logf("expected no error to be returned but received %v", err)
-	}
-
-	_, err = publishAlias(ctx, svc, "func1", "DEMO", "2")
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	versions, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func1"),
-		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1"),
-	}, GlobalCliConfig)
-	if err != nil {
-		t.Errorf("expected no error to be returned but received %v", err)
-	}
-
-	if len(versions) != 2 {
-		t.Errorf("expected 2 versions to be returned but received %v", len(versions))
-	}
-
-	_, err = getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
-		FunctionName: aws.String("func22"),
-		FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func22"),
-	}, GlobalCliConfig)
-	if err == nil {
-		t.Errorf("expected an error to be returned but received %v", err)
-	}
-
-	t.Cleanup(func() {
-		GlobalCliConfig = cliConfig{
-			RegionFlag:        aws.String(""),
-			CredentialsFile:   aws.Bool(false),
-			ProfileFlag:       aws.String(""),
-			DryRun:            aws.Bool(true),
-			Verbose:           aws.Bool(true),
-			LambdaListFile:    aws.String(""),
-			MoreLambdaDetails: aws.Bool(true),
-			SizeIEC:           aws.Bool(false),
-			SkipAliases:       aws.Bool(false),
-			Retain:            aws.Int8(0),
-		}
-
-	})
-
-}
-
 func TestExecuteClean(t *testing.T) {
 	ctx := context.TODO()
 	newNetwork, err := network.New(ctx)
@@ -1083,7 +102,7 @@ func TestExecuteClean(t *testing.T) {
 		t.Errorf("expected no error to be returned but received %v", err)
 	}
 
-	err = executeClean(ctx, &GlobalCliConfig, svc, []string{})
+	_, err = executeClean(ctx, &GlobalCliConfig, svc, []internal.LambdaEntry{})
 	if err != nil {
 		t.Errorf("expected no error to be returned but received %v", err)
 	}
@@ -1705,6 +724,264 @@ func TestAWSInvalidRegion(t *testing.T) {
 	})
 }
 
+func TestExecuteCleanCustomListEntries(t *testing.T) {
+
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 4)
+	svc.AddFunction("func2", 100, 4)
+	svc.AddFunction("func3", 100, 4)
+
+	config := cliConfig{
+		RegionFlag:        aws.String("us-east-1"),
+		Retain:            aws.Int8(1),
+		DryRun:            aws.Bool(false),
+		SkipAliases:       aws.Bool(false),
+		MoreLambdaDetails: aws.Bool(false),
+		SizeIEC:           aws.Bool(false),
+	}
+
+	customList := []internal.LambdaEntry{
+		{Name: "func1", Retain: aws.Int8(3)},
+		{Name: "arn:aws:lambda:us-east-1:000000000000:function:func2"},
+		{Name: "func3", Region: "eu-west-1"},
+	}
+
+	summary, err := executeClean(context.Background(), &config, svc, customList)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 2 || summary.Deleted != 4 {
+		t.Errorf("expected 4 versions of 2 Lambdas to be deleted but received %+v", summary)
+	}
+
+	for name, want := range map[string]int{"func1": 3, "func2": 1, "func3": 4} {
+		if got := len(svc.Versions(name)); got != want {
+			t.Errorf("expected %d versions of %s to remain but received %d", want, name, got)
+		}
+	}
+
+	summary, err = executeClean(context.Background(), &config, svc, customList[2:])
+	if err != nil || summary.Lambdas != 0 {
+		t.Errorf("expected no Lambda to be cleaned when no entry applies to the region but received %+v %v", summary, err)
+	}
+
+}
+
+func TestReadExcludeFile(t *testing.T) {
+
+	got, err := readExcludeFile("../tests/test-v2.yaml")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"stopEC2-instances", "putControls", "sendReports", "rotateKeys"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v but received %v", want, got)
+	}
+
+	_, err = readExcludeFile("../tests/invalid.json")
+	if err == nil {
+		t.Errorf("expected an error for an invalid exclude file but received %v", err)
+	}
+
+	_, err = readExcludeFile("../tests/missing.yaml")
+	if err == nil {
+		t.Errorf("expected an error for a missing exclude file but received %v", err)
+	}
+
+}
+
+func TestReadCustomList(t *testing.T) {
+
+	config := cliConfig{LambdaListFile: aws.String(internal.StdinSource)}
+
+	got, err := readCustomList(context.Background(), config, strings.NewReader("func1\nfunc2\n"), nil)
+	if err != nil || len(got) != 2 || got[1].Name != "func2" {
+		t.Errorf("expected func1 and func2 to be read from the standard input but received %v %v", got, err)
+	}
+
+	_, err = readCustomList(context.Background(), config, strings.NewReader(""), nil)
+	if err == nil {
+		t.Errorf("expected an error for an empty standard input but received %v", err)
+	}
+
+	got, err = readCustomList(context.Background(), config, strings.NewReader(`["func1", "func2"]`), nil)
+	if err != nil || len(got) != 2 || got[1].Name != "func2" {
+		t.Errorf("expected func1 and func2 to be read from a JSON array but received %v %v", got, err)
+	}
+
+	config.LambdaListFile = aws.String("../tests/missing.yaml")
+
+	_, err = readCustomList(context.Background(), config, nil, nil)
+	if err == nil {
+		t.Errorf("expected an error for a missing file but received %v", err)
+	}
+
+}
+
+func TestRunCleanInvalidCustomList(t *testing.T) {
+
+	var requests atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_PROFILE", "")
+	t.Setenv("AWS_CA_BUNDLE", "")
+
+	dir := t.TempDir()
+	list := filepath.Join(dir, "list.yaml")
+	awsConfigFile := filepath.Join(dir, "config")
+
+	t.Setenv("AWS_CONFIG_FILE", awsConfigFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", awsConfigFile)
+
+	for file, content := range map[string]string{
+		list:          "lambdas:\n  - func1\n  - name: func2\n    retain: -1\n",
+		awsConfigFile: "[default]\naws_access_key_id = test\naws_secret_access_key = test\n",
+	} {
+		err := os.WriteFile(file, []byte(content), 0o600)
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	_, err := runClean(context.Background(), cliConfig{
+		ProfileFlag:       aws.String("default"),
+		RegionFlag:        aws.String("us-east-1"),
+		Retain:            aws.Int8(1),
+		Verbose:           aws.Bool(false),
+		DryRun:            aws.Bool(false),
+		SkipAliases:       aws.Bool(false),
+		MoreLambdaDetails: aws.Bool(false),
+		SizeIEC:           aws.Bool(false),
+		LambdaListFile:    aws.String(list),
+		EndpointURL:       server.URL,
+	})
+	if err == nil {
+		t.Errorf("expected an invalid custom list to stop the clean-up but received %v", err)
+	}
+
+	if requests.Load() != 0 {
+		t.Errorf("expected no Lambda to be scanned but received %d requests", requests.Load())
+	}
+
+}
+
+func TestResolveEndpointURL(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		flag        string
+		env         map[string]string
+		want        string
+		expectedErr bool
+	}{
+		{
+			name: "no endpoint",
+			want: "",
+		},
+		{
+			name: "flag",
+			flag: "http://localhost:4566",
+			env:  map[string]string{"AWS_ENDPOINT_URL_LAMBDA": "http://lambda:4566"},
+			want: "http://localhost:4566",
+		},
+		{
+			name: "service env variable",
+			env:  map[string]string{"AWS_ENDPOINT_URL_LAMBDA": "http://lambda:4566", "AWS_ENDPOINT_URL": "http://global:4566"},
+			want: "http://lambda:4566",
+		},
+		{
+			name: "global env variable",
+			env:  map[string]string{"AWS_ENDPOINT_URL": "http://global:4566"},
+			want: "http://global:4566",
+		},
+		{
+			name: "ignored env variables",
+			env:  map[string]string{"AWS_ENDPOINT_URL": "http://global:4566", "AWS_IGNORE_CONFIGURED_ENDPOINT_URLS": "true"},
+			want: "",
+		},
+		{
+			name:        "invalid endpoint",
+			flag:        "localhost",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"AWS_ENDPOINT_URL", "AWS_ENDPOINT_URL_LAMBDA", "AWS_IGNORE_CONFIGURED_ENDPOINT_URLS"} {
+				t.Setenv(key, tc.env[key])
+			}
+
+			got, err := resolveEndpointURL(tc.flag)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected an error to be %v but received %v", tc.expectedErr, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected %s but received %s", tc.want, got)
+			}
+		})
+	}
+
+}
+
+func TestServiceClientsEndpointURL(t *testing.T) {
+
+	cfg := aws.Config{Region: "us-east-1"}
+	endpoint := "http://localhost:4566"
+
+	endpoints := map[string]*string{
+		"cloudformation": newCloudFormationClient(cfg, endpoint).Options().BaseEndpoint,
+		"sfn":            newStepFunctionsClient(cfg, endpoint).Options().BaseEndpoint,
+		"codedeploy":     newCodeDeployClient(cfg, endpoint).Options().BaseEndpoint,
+		"s3":             newS3Client(cfg, endpoint).Options().BaseEndpoint,
+	}
+	for service, got := range endpoints {
+		if got == nil || *got != endpoint {
+			t.Errorf("expected the %s client to use the endpoint %s but received %v", service, endpoint, got)
+		}
+	}
+
+	if !newS3Client(cfg, endpoint).Options().UsePathStyle {
+		t.Errorf("expected the S3 client to use path-style URLs with a custom endpoint")
+	}
+
+	if got := newCloudFormationClient(cfg, "").Options().BaseEndpoint; got != nil {
+		t.Errorf("expected the endpoint to be resolved by the AWS SDK but received %s", *got)
+	}
+
+}
+
+func TestInterruptibleContext(t *testing.T) {
+
+	ctx, cancel := interruptibleContext(context.Background())
+	defer cancel()
+
+	// Allow the signal handler to be registered before the signal is sent.
+	time.Sleep(time.Millisecond * 50)
+
+	err := syscall.Kill(os.Getpid(), syscall.SIGINT)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second * 5):
+		t.Fatalf("expected the context to be cancelled after receiving a SIGINT signal")
+	}
+
+}
+
 /*
 
 THE CODE BELOW IS FOR TESTING PURPOSES ONLY
@@ -1974,6 +1251,26 @@ func listFunctionVersions(ctx context.Context, svc *lambda.Client, funcName stri
 	return len(output.Versions), nil
 }
 
+// getAllLambdaVersion returns all the versions of a Lambda, including $LATEST. The clean-up equivalent lives in the cleaner package.
+func getAllLambdaVersion(ctx context.Context, svc *lambda.Client, item types.FunctionConfiguration, _ cliConfig) ([]types.FunctionConfiguration, error) {
+
+	var versions []types.FunctionConfiguration
+
+	p := lambda.NewListVersionsByFunctionPaginator(svc, &lambda.ListVersionsByFunctionInput{
+		FunctionName: item.FunctionName,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return versions, err
+		}
+
+		versions = append(versions, page.Versions...)
+	}
+
+	return versions, nil
+}
+
 func listFunctionAliases(ctx context.Context, svc *lambda.Client, funcName, funcVersion string) (int, []string, error) {
 
 	output, err := svc.ListAliases(ctx, &lambda.ListAliasesInput{
@@ -0,0 +1,161 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfnTypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// lambdaVersionResourceType is the CloudFormation resource type of a published Lambda version. SAM creates one for each AutoPublishAlias.
+	lambdaVersionResourceType string = "AWS::Lambda::Version"
+	// lambdaFunctionResourceType is the CloudFormation resource type of a Lambda. Its physical ID is the name of the Lambda.
+	lambdaFunctionResourceType string = "AWS::Lambda::Function"
+	// nestedStackResourceType is the CloudFormation resource type of a nested stack. Its physical ID is the ID of the nested stack.
+	nestedStackResourceType string = "AWS::CloudFormation::Stack"
+)
+
+// cloudFormationAPI is the subset of the AWS CloudFormation API used to find the Lambda versions referenced by stacks.
+type cloudFormationAPI interface {
+	cloudformation.ListStacksAPIClient
+	cloudformation.ListStackResourcesAPIClient
+}
+
+// newCloudFormationClient returns a CloudFormation API client that uses the AWS configuration.
+// The endpointURL of the --endpoint-url flag takes precedence. Otherwise, the endpoint is resolved by the AWS SDK, so the AWS_ENDPOINT_URL and AWS_ENDPOINT_URL_CLOUDFORMATION env variables are honored.
+func newCloudFormationClient(cfg aws.Config, endpointURL string) *cloudformation.Client {
+	return cloudformation.NewFromConfig(cfg, func(o *cloudformation.Options) {
+		o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+}
+
+// stackLambdaVersions returns the ARNs of the Lambda versions referenced by the AWS::Lambda::Version resources of every stack in the region.
+// The deleted stacks are ignored. An error is returned if a stack or its resources cannot be listed.
+func stackLambdaVersions(ctx context.Context, svc cloudFormationAPI) ([]string, error) {
+	var versions []string
+
+	p := cloudformation.NewListStacksPaginator(svc, &cloudformation.ListStacksInput{})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the CloudFormation stacks: %w", err)
+		}
+
+		for _, stack := range page.StackSummaries {
+			if stack.StackStatus == cfnTypes.StackStatusDeleteComplete {
+				continue
+			}
+
+			stackVersions, err := stackResourceVersions(ctx, svc, aws.ToString(stack.StackId))
+			if err != nil {
+				return nil, fmt.Errorf("unable to list the resources of the CloudFormation stack %s: %w", aws.ToString(stack.StackName), err)
+			}
+
+			if len(stackVersions) > 0 {
+				log.Debug(fmt.Sprintf("CloudFormation stack %s references %d Lambda versions", aws.ToString(stack.StackName), len(stackVersions)))
+			}
+
+			versions = append(versions, stackVersions...)
+		}
+	}
+
+	return versions, nil
+}
+
+// stackResourceVersions returns the physical IDs of the AWS::Lambda::Version resources of a stack. The physical ID of a version is its ARN.
+func stackResourceVersions(ctx context.Context, svc cloudFormationAPI, stackID string) ([]string, error) {
+	var versions []string
+
+	resources, err := stackResources(ctx, svc, stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resource := range resources {
+		// A resource that failed to be created does not have the ARN of a version as its physical ID.
+		if aws.ToString(resource.ResourceType) != lambdaVersionResourceType || !arn.IsARN(aws.ToString(resource.PhysicalResourceId)) {
+			continue
+		}
+
+		versions = append(versions, aws.ToString(resource.PhysicalResourceId))
+	}
+
+	return versions, nil
+}
+
+/*
+stackLambdaFunctions returns the names of the Lambdas created by the AWS::Lambda::Function resources of the stacks, in the order they are found.
+A stack is identified by its name or its ID. The Lambdas of the nested stacks are included when nested is true.
+An error is returned if the resources of a stack cannot be listed, so that a stack that cannot be read never results in a full scan of the region.
+*/
+func stackLambdaFunctions(ctx context.Context, svc cloudFormationAPI, stacks []string, nested bool) ([]string, error) {
+	var functions []string
+
+	seen := make(map[string]bool)
+
+	for len(stacks) > 0 {
+		stack := stacks[0]
+		stacks = stacks[1:]
+
+		resources, err := stackResources(ctx, svc, stack)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the resources of the CloudFormation stack %s: %w", stack, err)
+		}
+
+		for _, resource := range resources {
+			physicalID := aws.ToString(resource.PhysicalResourceId)
+			// A resource that is not created yet or that failed to be created does not have a physical ID.
+			if physicalID == "" {
+				continue
+			}
+
+			switch aws.ToString(resource.ResourceType) {
+			case lambdaFunctionResourceType:
+				if !seen[physicalID] {
+					seen[physicalID] = true
+					functions = append(functions, physicalID)
+				}
+
+			case nestedStackResourceType:
+				if nested {
+					log.Debug(fmt.Sprintf("Including the nested stack %s of %s", aws.ToString(resource.LogicalResourceId), stack))
+
+					stacks = append(stacks, physicalID)
+				}
+			}
+		}
+	}
+
+	return functions, nil
+}
+
+// stackResources returns the resources of a stack identified by its name or its ID.
+func stackResources(ctx context.Context, svc cloudFormationAPI, stack string) ([]cfnTypes.StackResourceSummary, error) {
+	var resources []cfnTypes.StackResourceSummary
+
+	p := cloudformation.NewListStackResourcesPaginator(svc, &cloudformation.ListStackResourcesInput{
+		StackName: aws.String(stack),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, page.StackResourceSummaries...)
+	}
+
+	return resources, nil
+}
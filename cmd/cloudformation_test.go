@@ -0,0 +1,118 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudformation"
+	cfnTypes "github.com/aws/aws-sdk-go-v2/service/cloudformation/types"
+)
+
+// fakeCloudFormation is an in-memory CloudFormation API that returns the resources of each stack, keyed by the stack ID.
+type fakeCloudFormation struct {
+	stacks    []cfnTypes.StackSummary
+	resources map[string][]cfnTypes.StackResourceSummary
+	err       error
+}
+
+func (f *fakeCloudFormation) ListStacks(_ context.Context, _ *cloudformation.ListStacksInput, _ ...func(*cloudformation.Options)) (*cloudformation.ListStacksOutput, error) {
+	return &cloudformation.ListStacksOutput{StackSummaries: f.stacks}, nil
+}
+
+func (f *fakeCloudFormation) ListStackResources(_ context.Context, params *cloudformation.ListStackResourcesInput, _ ...func(*cloudformation.Options)) (*cloudformation.ListStackResourcesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &cloudformation.ListStackResourcesOutput{StackResourceSummaries: f.resources[*params.StackName]}, nil
+}
+
+func TestStackLambdaVersions(t *testing.T) {
+
+	svc := &fakeCloudFormation{
+		stacks: []cfnTypes.StackSummary{
+			{StackId: aws.String("api-id"), StackName: aws.String("api"), StackStatus: cfnTypes.StackStatusUpdateComplete},
+			{StackId: aws.String("old-id"), StackName: aws.String("old"), StackStatus: cfnTypes.StackStatusDeleteComplete},
+		},
+		resources: map[string][]cfnTypes.StackResourceSummary{
+			"api-id": {
+				{ResourceType: aws.String("AWS::Lambda::Function"), PhysicalResourceId: aws.String("api-handler")},
+				{ResourceType: aws.String("AWS::Lambda::Version"), PhysicalResourceId: aws.String("arn:aws:lambda:us-east-1:000000000000:function:api-handler:7")},
+				{ResourceType: aws.String("AWS::Lambda::Version"), PhysicalResourceId: aws.String("ApiHandlerVersion1a2b3c")},
+			},
+			"old-id": {
+				{ResourceType: aws.String("AWS::Lambda::Version"), PhysicalResourceId: aws.String("arn:aws:lambda:us-east-1:000000000000:function:old-handler:1")},
+			},
+		},
+	}
+
+	got, err := stackLambdaVersions(context.Background(), svc)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"arn:aws:lambda:us-east-1:000000000000:function:api-handler:7"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	svc.err = errors.New("AccessDenied")
+
+	_, err = stackLambdaVersions(context.Background(), svc)
+	if err == nil {
+		t.Errorf("expected an error to be returned when the stack resources cannot be listed but received %v", err)
+	}
+
+}
+
+func TestStackLambdaFunctions(t *testing.T) {
+
+	svc := &fakeCloudFormation{
+		resources: map[string][]cfnTypes.StackResourceSummary{
+			"orders-service": {
+				{ResourceType: aws.String("AWS::Lambda::Function"), PhysicalResourceId: aws.String("orders-api")},
+				{ResourceType: aws.String("AWS::Lambda::Version"), PhysicalResourceId: aws.String("arn:aws:lambda:us-east-1:000000000000:function:orders-api:7")},
+				{ResourceType: aws.String("AWS::Lambda::Function"), LogicalResourceId: aws.String("Pending")},
+				{ResourceType: aws.String("AWS::CloudFormation::Stack"), LogicalResourceId: aws.String("Workers"), PhysicalResourceId: aws.String("workers-id")},
+			},
+			"workers-id": {
+				{ResourceType: aws.String("AWS::Lambda::Function"), PhysicalResourceId: aws.String("orders-worker")},
+				{ResourceType: aws.String("AWS::Lambda::Function"), PhysicalResourceId: aws.String("orders-api")},
+			},
+		},
+	}
+
+	got, err := stackLambdaFunctions(context.Background(), svc, []string{"orders-service"}, false)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"orders-api"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	got, err = stackLambdaFunctions(context.Background(), svc, []string{"orders-service"}, true)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want = []string{"orders-api", "orders-worker"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned with the nested stacks but received %v", want, got)
+	}
+
+	svc.err = errors.New("ValidationError: Stack with id missing does not exist")
+
+	_, err = stackLambdaFunctions(context.Background(), svc, []string{"missing"}, false)
+	if err == nil {
+		t.Errorf("expected an error to be returned when the stack resources cannot be listed but received %v", err)
+	}
+
+}
@@ -0,0 +1,230 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/codedeploy"
+	cdTypes "github.com/aws/aws-sdk-go-v2/service/codedeploy/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	log "github.com/sirupsen/logrus"
+	yaml "gopkg.in/yaml.v3"
+)
+
+const (
+	// codeDeployBatchSize is the maximum number of deployments per BatchGetDeployments request.
+	codeDeployBatchSize int = 25
+	// appSpecLambdaType is the resource type of a Lambda in an AppSpec file.
+	appSpecLambdaType string = "AWS::Lambda::Function"
+)
+
+// codeDeployActiveStatuses are the statuses of the deployments that have not finished.
+var codeDeployActiveStatuses = []cdTypes.DeploymentStatus{
+	cdTypes.DeploymentStatusCreated,
+	cdTypes.DeploymentStatusQueued,
+	cdTypes.DeploymentStatusInProgress,
+	cdTypes.DeploymentStatusBaking,
+	cdTypes.DeploymentStatusReady,
+}
+
+// codeDeployAPI is the subset of the AWS CodeDeploy API used to read the revisions of the active deployments.
+type codeDeployAPI interface {
+	codedeploy.ListDeploymentsAPIClient
+	BatchGetDeployments(ctx context.Context, params *codedeploy.BatchGetDeploymentsInput, optFns ...func(*codedeploy.Options)) (*codedeploy.BatchGetDeploymentsOutput, error)
+}
+
+// appSpec is the subset of an AppSpec file of a Lambda deployment.
+type appSpec struct {
+	Resources []map[string]struct {
+		Type       string `yaml:"Type"`
+		Properties struct {
+			Name           string `yaml:"Name"`
+			CurrentVersion string `yaml:"CurrentVersion"`
+			TargetVersion  string `yaml:"TargetVersion"`
+		} `yaml:"Properties"`
+	} `yaml:"Resources"`
+}
+
+// newCodeDeployClient returns an AWS CodeDeploy API client that uses the AWS configuration.
+// The endpointURL of the --endpoint-url flag takes precedence. Otherwise, the endpoint is resolved by the AWS SDK, so the AWS_ENDPOINT_URL and AWS_ENDPOINT_URL_CODEDEPLOY env variables are honored.
+func newCodeDeployClient(cfg aws.Config, endpointURL string) *codedeploy.Client {
+	return codedeploy.NewFromConfig(cfg, func(o *codedeploy.Options) {
+		o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+}
+
+// activeDeployments returns the IDs of the deployments of the region that have not finished.
+func activeDeployments(ctx context.Context, svc codeDeployAPI) ([]string, error) {
+	var ids []string
+
+	p := codedeploy.NewListDeploymentsPaginator(svc, &codedeploy.ListDeploymentsInput{
+		IncludeOnlyStatuses: codeDeployActiveStatuses,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, page.Deployments...)
+	}
+
+	return ids, nil
+}
+
+// getDeployments returns the deployments with the IDs. The deployments are requested in batches of codeDeployBatchSize.
+func getDeployments(ctx context.Context, svc codeDeployAPI, ids []string) ([]cdTypes.DeploymentInfo, error) {
+	var deployments []cdTypes.DeploymentInfo
+
+	for start := 0; start < len(ids); start += codeDeployBatchSize {
+		end := min(start+codeDeployBatchSize, len(ids))
+
+		output, err := svc.BatchGetDeployments(ctx, &codedeploy.BatchGetDeploymentsInput{
+			DeploymentIds: ids[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		deployments = append(deployments, output.DeploymentsInfo...)
+	}
+
+	return deployments, nil
+}
+
+/*
+codeDeployLambdaVersions returns the source and target Lambda versions of the AppSpec revisions of the Lambda deployments of the region that have not finished.
+A version is returned as the name of the Lambda qualified with the version, such as my-function:17.
+The revisions stored in Amazon S3 are downloaded with the S3 client. An error is returned if a deployment or its revision cannot be read.
+*/
+func codeDeployLambdaVersions(ctx context.Context, svc codeDeployAPI, s3svc internal.S3GetObjectAPI) ([]string, error) {
+	seen := make(map[string]bool)
+
+	ids, err := activeDeployments(ctx, svc)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the CodeDeploy deployments: %w", err)
+	}
+
+	deployments, err := getDeployments(ctx, svc, ids)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the CodeDeploy deployments: %w", err)
+	}
+
+	for _, deployment := range deployments {
+		if deployment.ComputePlatform != cdTypes.ComputePlatformLambda {
+			continue
+		}
+
+		content, err := deploymentAppSpec(ctx, deployment, s3svc)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the AppSpec of the deployment %s: %w", aws.ToString(deployment.DeploymentId), err)
+		}
+
+		versions, err := appSpecLambdaVersions(content)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse the AppSpec of the deployment %s: %w", aws.ToString(deployment.DeploymentId), err)
+		}
+
+		log.Debug(fmt.Sprintf("Deployment %s shifts traffic between %d Lambda versions", aws.ToString(deployment.DeploymentId), len(versions)))
+
+		for _, version := range versions {
+			seen[version] = true
+		}
+	}
+
+	output := make([]string, 0, len(seen))
+	for version := range seen {
+		output = append(output, version)
+	}
+
+	sort.Strings(output)
+
+	return output, nil
+}
+
+// deploymentAppSpec returns the content of the AppSpec revision of a deployment.
+func deploymentAppSpec(ctx context.Context, deployment cdTypes.DeploymentInfo, s3svc internal.S3GetObjectAPI) ([]byte, error) {
+	revision := deployment.Revision
+	if revision == nil {
+		return nil, errors.New("the deployment does not have a revision")
+	}
+
+	switch revision.RevisionType {
+	case cdTypes.RevisionLocationTypeAppSpecContent:
+		if revision.AppSpecContent == nil {
+			return nil, errors.New("the revision does not have an AppSpec content")
+		}
+
+		return []byte(aws.ToString(revision.AppSpecContent.Content)), nil
+
+	case cdTypes.RevisionLocationTypeString:
+		if revision.String_ == nil {
+			return nil, errors.New("the revision does not have a content")
+		}
+
+		return []byte(aws.ToString(revision.String_.Content)), nil
+
+	case cdTypes.RevisionLocationTypeS3:
+		if revision.S3Location == nil {
+			return nil, errors.New("the revision does not have an Amazon S3 location")
+		}
+
+		out, err := s3svc.GetObject(ctx, &s3.GetObjectInput{
+			Bucket:    revision.S3Location.Bucket,
+			Key:       revision.S3Location.Key,
+			VersionId: revision.S3Location.Version,
+		})
+		if err != nil {
+			return nil, err
+		}
+		defer out.Body.Close()
+
+		return io.ReadAll(out.Body)
+
+	default:
+		return nil, fmt.Errorf("unsupported revision type %s", revision.RevisionType)
+	}
+}
+
+// appSpecLambdaVersions returns the current and target versions of the Lambdas of an AppSpec file in the YAML or JSON format.
+func appSpecLambdaVersions(content []byte) ([]string, error) {
+	var (
+		spec     appSpec
+		versions []string
+	)
+
+	err := yaml.Unmarshal(content, &spec)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, resources := range spec.Resources {
+		for _, resource := range resources {
+			if resource.Type != "" && resource.Type != appSpecLambdaType {
+				continue
+			}
+
+			for _, version := range []string{resource.Properties.CurrentVersion, resource.Properties.TargetVersion} {
+				if qualified, ok := qualifiedLambdaVersion(resource.Properties.Name + ":" + version); ok {
+					versions = append(versions, qualified)
+				}
+			}
+		}
+	}
+
+	sort.Strings(versions)
+
+	return versions, nil
+}
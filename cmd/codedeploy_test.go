@@ -0,0 +1,198 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/codedeploy"
+	cdTypes "github.com/aws/aws-sdk-go-v2/service/codedeploy/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const testAppSpec = `version: 0.0
+Resources:
+  - checkout:
+      Type: AWS::Lambda::Function
+      Properties:
+        Name: checkout
+        Alias: live
+        CurrentVersion: 4
+        TargetVersion: 5
+Hooks:
+  - BeforeAllowTraffic: checkout-validate
+`
+
+// fakeAppSpecBucket is an Amazon S3 API that returns the AppSpec file of the revisions stored in Amazon S3, keyed by object key.
+type fakeAppSpecBucket map[string]string
+
+func (f fakeAppSpecBucket) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	content, ok := f[*params.Key]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(content))}, nil
+}
+
+// fakeCodeDeploy is an in-memory AWS CodeDeploy API. Each deployment ID is listed on its own page.
+type fakeCodeDeploy struct {
+	deployments []cdTypes.DeploymentInfo
+	requested   []string
+	batches     []int
+}
+
+func (f *fakeCodeDeploy) ListDeployments(_ context.Context, params *codedeploy.ListDeploymentsInput, _ ...func(*codedeploy.Options)) (*codedeploy.ListDeploymentsOutput, error) {
+	if !slices.Equal(params.IncludeOnlyStatuses, codeDeployActiveStatuses) {
+		return nil, errors.New("ValidationException: only the active deployments must be listed")
+	}
+
+	i := 0
+	if params.NextToken != nil {
+		i, _ = strconv.Atoi(*params.NextToken)
+	}
+
+	output := &codedeploy.ListDeploymentsOutput{}
+	if i < len(f.deployments) {
+		output.Deployments = []string{aws.ToString(f.deployments[i].DeploymentId)}
+	}
+
+	if i+1 < len(f.deployments) {
+		output.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+
+	return output, nil
+}
+
+func (f *fakeCodeDeploy) BatchGetDeployments(_ context.Context, params *codedeploy.BatchGetDeploymentsInput, _ ...func(*codedeploy.Options)) (*codedeploy.BatchGetDeploymentsOutput, error) {
+	f.batches = append(f.batches, len(params.DeploymentIds))
+	f.requested = append(f.requested, params.DeploymentIds...)
+
+	output := &codedeploy.BatchGetDeploymentsOutput{}
+
+	for _, deployment := range f.deployments {
+		if slices.Contains(params.DeploymentIds, aws.ToString(deployment.DeploymentId)) {
+			output.DeploymentsInfo = append(output.DeploymentsInfo, deployment)
+		}
+	}
+
+	return output, nil
+}
+
+func TestAppSpecLambdaVersions(t *testing.T) {
+
+	got, err := appSpecLambdaVersions([]byte(testAppSpec))
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"checkout:4", "checkout:5"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	got, err = appSpecLambdaVersions([]byte(`{"version":0.0,"Resources":[{"api":{"Type":"AWS::Lambda::Function","Properties":{"Name":"arn:aws:lambda:us-east-1:000000000000:function:api","Alias":"live","CurrentVersion":"1","TargetVersion":"2"}}}]}`))
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want = []string{"api:1", "api:2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	_, err = appSpecLambdaVersions([]byte("Resources: ["))
+	if err == nil {
+		t.Errorf("expected an error to be returned for an invalid AppSpec but received %v", err)
+	}
+
+}
+
+func TestCodeDeployLambdaVersions(t *testing.T) {
+
+	svc := &fakeCodeDeploy{
+		deployments: []cdTypes.DeploymentInfo{
+			{
+				DeploymentId:    aws.String("d-STRING"),
+				ComputePlatform: cdTypes.ComputePlatformLambda,
+				Revision: &cdTypes.RevisionLocation{
+					RevisionType:   cdTypes.RevisionLocationTypeAppSpecContent,
+					AppSpecContent: &cdTypes.AppSpecContent{Content: aws.String(testAppSpec)},
+				},
+			},
+			{
+				DeploymentId:    aws.String("d-S3"),
+				ComputePlatform: cdTypes.ComputePlatformLambda,
+				Revision: &cdTypes.RevisionLocation{
+					RevisionType: cdTypes.RevisionLocationTypeS3,
+					S3Location:   &cdTypes.S3Location{Bucket: aws.String("revisions"), Key: aws.String("orders/appspec.yaml")},
+				},
+			},
+			{
+				DeploymentId:    aws.String("d-ECS"),
+				ComputePlatform: cdTypes.ComputePlatformEcs,
+				Revision: &cdTypes.RevisionLocation{
+					RevisionType: cdTypes.RevisionLocationTypeS3,
+					S3Location:   &cdTypes.S3Location{Bucket: aws.String("revisions"), Key: aws.String("missing.yaml")},
+				},
+			},
+		},
+	}
+
+	bucket := fakeAppSpecBucket{
+		"orders/appspec.yaml": strings.ReplaceAll(testAppSpec, "checkout", "orders"),
+	}
+
+	got, err := codeDeployLambdaVersions(context.Background(), svc, bucket)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"checkout:4", "checkout:5", "orders:4", "orders:5"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	if !slices.Equal(svc.requested, []string{"d-STRING", "d-S3", "d-ECS"}) {
+		t.Errorf("expected the 3 active deployments to be requested but received %v", svc.requested)
+	}
+
+	delete(bucket, "orders/appspec.yaml")
+
+	_, err = codeDeployLambdaVersions(context.Background(), svc, bucket)
+	if err == nil {
+		t.Errorf("expected an error to be returned when a revision cannot be read but received %v", err)
+	}
+
+}
+
+func TestGetDeploymentsBatches(t *testing.T) {
+
+	svc := &fakeCodeDeploy{}
+
+	var ids []string
+	for i := range 30 {
+		id := fmt.Sprintf("d-%d", i)
+		ids = append(ids, id)
+		svc.deployments = append(svc.deployments, cdTypes.DeploymentInfo{DeploymentId: aws.String(id)})
+	}
+
+	got, err := getDeployments(context.Background(), svc, ids)
+	if err != nil || len(got) != 30 {
+		t.Fatalf("expected the 30 deployments to be returned but received %d %v", len(got), err)
+	}
+
+	if !slices.Equal(svc.batches, []int{25, 5}) {
+		t.Errorf("expected the deployments to be requested in batches of 25 and 5 but received %v", svc.batches)
+	}
+
+}
@@ -0,0 +1,263 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	// envPrefix is the prefix of the environment variables that override the configuration file.
+	envPrefix string = "GLC_"
+	// configEnvVar is the environment variable that points to the configuration file.
+	configEnvVar string = envPrefix + "CONFIG"
+)
+
+// The sources of an effective configuration value, from the lowest to the highest precedence.
+const (
+	sourceDefault string = "default"
+	sourceFile    string = "file"
+	sourceEnv     string = "env"
+	sourceFlag    string = "flag"
+)
+
+// configLayers records the configuration file used and the source of the effective value of each flag.
+type configLayers struct {
+	path    string
+	sources map[string]string
+	targets map[string]internal.Target
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configViewCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the glc configuration",
+	Long:  `Manage the glc configuration. The configuration file sets default values for the flags, GLC_* environment variables override the file, and flags override both.`,
+}
+
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Display the effective configuration",
+	Long:  `Display the effective configuration and the source of each value: default, file, env, or flag.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		if effectiveConfig.path != "" {
+			fmt.Fprintf(out, "# Configuration file: %s\n", effectiveConfig.path)
+		} else {
+			fmt.Fprintln(out, "# Configuration file: none")
+		}
+
+		for _, f := range configurableFlags(rootCmd.PersistentFlags(), cleanCmd.Flags()) {
+			source, ok := effectiveConfig.sources[f.Name]
+			if !ok {
+				source = sourceDefault
+			}
+
+			value := f.Value.String()
+			if f.Value.Type() == "string" {
+				value = strconv.Quote(value)
+			}
+
+			fmt.Fprintf(out, "%s: %s # %s\n", f.Name, value, source)
+		}
+
+		if len(effectiveConfig.targets) > 0 {
+			names := make([]string, 0, len(effectiveConfig.targets))
+			for name := range effectiveConfig.targets {
+				names = append(names, name)
+			}
+
+			sort.Strings(names)
+
+			fmt.Fprintf(out, "# Targets: %s\n", strings.Join(names, ", "))
+		}
+
+		return nil
+	},
+}
+
+/*
+loadConfig applies the configuration file and the GLC_* environment variables to the flags that were not provided on the command line.
+The environment variables override the configuration file. An error is returned if the file contains an unknown setting or an invalid value.
+If no path is provided, the GLC_CONFIG environment variable and then the default configuration file are used. A missing default file is ignored.
+*/
+func loadConfig(path string, flagSets ...*pflag.FlagSet) (configLayers, error) {
+	layers := configLayers{
+		sources: make(map[string]string),
+	}
+
+	if path == "" {
+		path = os.Getenv(configEnvVar)
+	}
+
+	if path == "" {
+		defaultPath, err := defaultConfigPath()
+		if err == nil {
+			if _, err := os.Stat(defaultPath); err == nil {
+				path = defaultPath
+			}
+		}
+	}
+
+	var settings map[string]any
+
+	if path != "" {
+		file, err := internal.ReadCLIConfigFile(path)
+		if err != nil {
+			return layers, err
+		}
+
+		layers.path = path
+		layers.targets = file.Targets
+		settings = file.Settings
+	}
+
+	flags := configurableFlags(flagSets...)
+
+	known := make(map[string]bool)
+	for _, f := range flags {
+		known[f.Name] = true
+	}
+
+	var unknown []string
+
+	for key := range settings {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+
+		return layers, fmt.Errorf("the configuration file %s contains unknown settings: %s", path, strings.Join(unknown, ", "))
+	}
+
+	for _, f := range flags {
+		if f.Changed {
+			layers.sources[f.Name] = sourceFlag
+
+			continue
+		}
+
+		envName := configEnvName(f.Name)
+		if value, ok := os.LookupEnv(envName); ok {
+			err := f.Value.Set(value)
+			if err != nil {
+				return layers, fmt.Errorf("invalid value %q for %s: %w", value, envName, err)
+			}
+
+			layers.sources[f.Name] = sourceEnv
+
+			continue
+		}
+
+		if value, ok := settings[f.Name]; ok {
+			err := f.Value.Set(configValue(value))
+			if err != nil {
+				return layers, fmt.Errorf("invalid value %v for %s in the configuration file %s: %w", value, f.Name, path, err)
+			}
+
+			layers.sources[f.Name] = sourceFile
+
+			continue
+		}
+
+		layers.sources[f.Name] = sourceDefault
+	}
+
+	return layers, nil
+}
+
+// configurableFlags returns the flags that accept a value from the configuration file, sorted by name.
+// The config and help flags are excluded.
+func configurableFlags(flagSets ...*pflag.FlagSet) []*pflag.Flag {
+	var flags []*pflag.Flag
+
+	seen := make(map[string]bool)
+
+	for _, fs := range flagSets {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if f.Name == "config" || f.Name == "help" || seen[f.Name] {
+				return
+			}
+
+			seen[f.Name] = true
+			flags = append(flags, f)
+		})
+	}
+
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+
+	return flags
+}
+
+// configEnvName returns the environment variable that overrides a flag. For example, skip-aliases is overridden by GLC_SKIP_ALIASES and listFile by GLC_LIST_FILE.
+func configEnvName(flag string) string {
+	var b strings.Builder
+
+	b.WriteString(envPrefix)
+
+	for i, r := range flag {
+		switch {
+		case r == '-':
+			b.WriteRune('_')
+		case unicode.IsUpper(r) && i > 0:
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+
+	return b.String()
+}
+
+// configValue returns the flag representation of a configuration file value. Lists are joined with commas.
+func configValue(value any) string {
+	list, ok := value.([]any)
+	if !ok {
+		return fmt.Sprint(value)
+	}
+
+	items := make([]string, 0, len(list))
+	for _, item := range list {
+		items = append(items, fmt.Sprint(item))
+	}
+
+	return strings.Join(items, ",")
+}
+
+// defaultConfigPath returns the default location of the configuration file: $XDG_CONFIG_HOME/glc/config.yaml or ~/.config/glc/config.yaml.
+func defaultConfigPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", errors.New("unable to determine the home directory of the user")
+		}
+
+		configHome = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(configHome, "glc", "config.yaml"), nil
+}
@@ -0,0 +1,218 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// newTestFlagSet returns a flag set with a subset of the glc flags bound to local variables.
+func newTestFlagSet(region, profile *string, count *int8, skipAliases *bool) *pflag.FlagSet {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.StringVarP(region, "region", "r", "", "")
+	fs.StringVarP(profile, "profile", "p", "", "")
+	fs.Int8VarP(count, "count", "c", 1, "")
+	fs.BoolVarP(skipAliases, "skip-aliases", "s", false, "")
+	fs.BoolP("size-iec", "i", false, "")
+
+	return fs
+}
+
+func TestLoadConfig(t *testing.T) {
+
+	var (
+		region      string
+		profile     string
+		count       int8
+		skipAliases bool
+	)
+
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("GLC_CONFIG", "")
+	t.Setenv("GLC_COUNT", "3")
+
+	fs := newTestFlagSet(&region, &profile, &count, &skipAliases)
+
+	err := fs.Parse([]string{"-r", "eu-west-1"})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	layers, err := loadConfig("../tests/config.yaml", fs)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if region != "eu-west-1" || layers.sources["region"] != sourceFlag {
+		t.Errorf("expected the region flag to override the file but received %s from %s", region, layers.sources["region"])
+	}
+
+	if count != 3 || layers.sources["count"] != sourceEnv {
+		t.Errorf("expected the GLC_COUNT env variable to override the file but received %d from %s", count, layers.sources["count"])
+	}
+
+	if profile != "default" || !skipAliases || layers.sources["profile"] != sourceFile {
+		t.Errorf("expected the profile and skip-aliases values of the file but received %s and %v", profile, skipAliases)
+	}
+
+	if layers.path != "../tests/config.yaml" {
+		t.Errorf("expected the configuration file to be ../tests/config.yaml but received %s", layers.path)
+	}
+
+}
+
+func TestLoadConfigDefaultFile(t *testing.T) {
+
+	var (
+		region      string
+		profile     string
+		count       int8
+		skipAliases bool
+	)
+
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GLC_CONFIG", "")
+
+	fs := newTestFlagSet(&region, &profile, &count, &skipAliases)
+
+	layers, err := loadConfig("", fs)
+	if err != nil {
+		t.Fatalf("expected a missing default file to be ignored but received %v", err)
+	}
+
+	if layers.path != "" || count != 1 || layers.sources["count"] != sourceDefault {
+		t.Errorf("expected the default values to be used but received %d from %s", count, layers.sources["count"])
+	}
+
+	path := filepath.Join(configHome, "glc", "config.yaml")
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(path, []byte("region: ap-south-1\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layers, err = loadConfig("", fs)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if layers.path != path || region != "ap-south-1" {
+		t.Errorf("expected the region of the default file but received %s from %s", region, layers.path)
+	}
+
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+
+	var (
+		region      string
+		profile     string
+		count       int8
+		skipAliases bool
+	)
+
+	dir := t.TempDir()
+	t.Setenv("GLC_CONFIG", "")
+
+	unknown := filepath.Join(dir, "unknown.yaml")
+
+	err := os.WriteFile(unknown, []byte("region: us-east-1\nretain: 2\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = loadConfig(unknown, newTestFlagSet(&region, &profile, &count, &skipAliases))
+	if err == nil || !strings.Contains(err.Error(), "retain") {
+		t.Errorf("expected an error for the unknown setting but received %v", err)
+	}
+
+	invalid := filepath.Join(dir, "invalid.yaml")
+
+	err = os.WriteFile(invalid, []byte("count: many\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = loadConfig(invalid, newTestFlagSet(&region, &profile, &count, &skipAliases))
+	if err == nil {
+		t.Errorf("expected an error for the invalid count but received %v", err)
+	}
+
+	t.Setenv("GLC_SKIP_ALIASES", "maybe")
+
+	_, err = loadConfig("../tests/config.yaml", newTestFlagSet(&region, &profile, &count, &skipAliases))
+	if err == nil || !strings.Contains(err.Error(), "GLC_SKIP_ALIASES") {
+		t.Errorf("expected an error for the invalid env variable but received %v", err)
+	}
+
+	_, err = loadConfig(filepath.Join(dir, "missing.yaml"), newTestFlagSet(&region, &profile, &count, &skipAliases))
+	if err == nil {
+		t.Errorf("expected an error for a missing configuration file but received %v", err)
+	}
+
+}
+
+func TestConfigEnvName(t *testing.T) {
+
+	tests := map[string]string{
+		"region":            "GLC_REGION",
+		"skip-aliases":      "GLC_SKIP_ALIASES",
+		"listFile":          "GLC_LIST_FILE",
+		"moreLambdaDetails": "GLC_MORE_LAMBDA_DETAILS",
+	}
+
+	for flag, want := range tests {
+		got := configEnvName(flag)
+		if got != want {
+			t.Errorf("expected %s but received %s", want, got)
+		}
+	}
+
+}
+
+func TestConfigViewCmd(t *testing.T) {
+
+	effectiveConfig = configLayers{
+		path:    "../tests/config.yaml",
+		sources: map[string]string{"region": sourceFile},
+	}
+
+	var buf bytes.Buffer
+	configViewCmd.SetOut(&buf)
+
+	t.Cleanup(func() {
+		effectiveConfig = configLayers{}
+		configViewCmd.SetOut(nil)
+	})
+
+	err := configViewCmd.RunE(configViewCmd, []string{})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	output := buf.String()
+
+	for _, want := range []string{"# Configuration file: ../tests/config.yaml", "# file", "count: 1 # default", "skip-aliases: false # default"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected the output to contain %s but received %s", want, output)
+		}
+	}
+
+	if strings.Contains(output, "config:") || strings.Contains(output, "help:") {
+		t.Errorf("expected the config and help flags to be excluded but received %s", output)
+	}
+
+}
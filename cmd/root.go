@@ -5,9 +5,11 @@ package cmd
 
 import (
 	"crypto/tls"
+	"errors"
 	"net/http"
 	"os"
 
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/spf13/cobra"
@@ -30,6 +32,26 @@ var (
 	DryRun bool
 	// LambdaListFile points a file that contains a listof Lambdas to delete.
 	LambdaListFile string
+	// ListColumn selects the column of a CSV list by its header name or by its 1-based index.
+	ListColumn string
+	// ExcludeFile points to a file that contains a list of Lambdas that must never be cleaned.
+	ExcludeFile string
+	// KeepDescription are the patterns of the descriptions of versions that are always retained.
+	KeepDescription []string
+	// ProtectCfn indicates that the Lambda versions referenced by CloudFormation stacks should never be deleted.
+	ProtectCfn bool
+	// ProtectSfn indicates that the Lambda versions referenced by Step Functions state machines should never be deleted.
+	ProtectSfn bool
+	// ProtectIntegrations indicates that the versions with a version-specific resource-based policy or event invoke configuration should be skipped.
+	ProtectIntegrations bool
+	// StackNames are the CloudFormation stacks whose Lambdas are cleaned instead of all the Lambdas of the region.
+	StackNames []string
+	// NestedStacks indicates that the Lambdas of the nested stacks of the StackNames should be included.
+	NestedStacks bool
+	// TagOverrides indicates that the glc:retain, glc:skip, and glc:max-age tags of each Lambda should be applied.
+	TagOverrides bool
+	// ProtectCodeDeploy indicates that the Lambda versions of the CodeDeploy deployments in progress should never be deleted.
+	ProtectCodeDeploy bool
 	// MoreLambdaDetails is to show information about the Lambda being worked on.
 	MoreLambdaDetails bool
 	// SizeIEC is used to display the size in IEC units.
@@ -40,19 +62,58 @@ var (
 	GlobalHTTPClient *http.Client
 	// UserAgent is the value to use for the User-Agent header.
 	UserAgent string
+	// SkipUpdating indicates that the Lambdas that are being deployed should be skipped.
+	SkipUpdating bool
+	// UpdateGracePeriod skips the Lambdas modified less than the age ago, such as 30m or 1d.
+	UpdateGracePeriod string
+	// PruneAliases are the glob patterns of the names of the aliases to delete before the versions are cleaned.
+	PruneAliases []string
+	// AliasOlderThan limits the pruning to the aliases that point to a version older than the age, such as 30d.
+	AliasOlderThan string
+	// RetainBehindAlias is the number of versions immediately older than the version of each alias to retain.
+	RetainBehindAlias int8
 	// SkipAliases indicates that lambda versions attached to an alias should be skipped from deletion.
 	SkipAliases bool
+	// Verify indicates that the deleted versions should be confirmed as removed by rescanning the Lambdas.
+	Verify bool
+	// Concurrency is the maximum number of AWS API operations executed at the same time.
+	Concurrency int
+	// RateLimit is the maximum number of AWS API operations started per second.
+	RateLimit float64
+	// StateFile points to a file that records the progress of the clean-up.
+	StateFile string
+	// ResumeFile points to a state file of an interrupted clean-up to resume.
+	ResumeFile string
+	// EndpointURL is a custom endpoint URL for the AWS APIs.
+	EndpointURL string
+	// Target is the name of a target of the configuration file to clean.
+	Target string
+	// AllTargets indicates that every target of the configuration file should be cleaned in sequence.
+	AllTargets bool
+	// ConfigFile points to a configuration file that sets default values for the flags.
+	ConfigFile string
+	// effectiveConfig records the configuration file used and the source of each flag value.
+	effectiveConfig configLayers
 )
 
 const (
 	// IssueMSG is a default message to pass to the user.
 	IssueMSG = " Please open up a Github issue to report this error! https://github.com/karl-cardenas-coding/go-clean-lambda"
+	// ExitCodeInterrupted is the exit code used when the execution is interrupted by a SIGINT or SIGTERM signal.
+	ExitCodeInterrupted = 130
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "glc",
 	Short: "A CLI tool for cleaning up AWS Lambda versions",
 	Long:  `A CLI tool for cleaning up AWS Lambda versions`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		var err error
+
+		effectiveConfig, err = loadConfig(ConfigFile, cmd.Flags(), cleanCmd.Flags())
+
+		return err
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		err := cmd.Help()
 		if err != nil {
@@ -66,15 +127,39 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&ConfigFile, "config", "", "Specify a configuration file. Defaults to ~/.config/glc/config.yaml")
 	rootCmd.PersistentFlags().StringVarP(&RegionFlag, "region", "r", "", "Specify the desired AWS region to target.")
 	rootCmd.PersistentFlags().StringVarP(&ProfileFlag, "profile", "p", "", "Specify the AWS profile to leverage for authentication.")
 	rootCmd.PersistentFlags().StringVarP(&LambdaListFile, "listFile", "l", "", "Specify a file containing Lambdas to delete.")
+	rootCmd.PersistentFlags().StringVar(&ListColumn, "listColumn", "", "Select the column of a CSV list by its header name or 1-based index. Defaults to the first column")
 	rootCmd.PersistentFlags().BoolVarP(&MoreLambdaDetails, "moreLambdaDetails", "m", false, "Set to true to show Lambda names and count of versions to be removed (bool)")
 	rootCmd.PersistentFlags().BoolVarP(&Verbose, "verbose", "v", false, "Set to true to enable debugging (bool)")
 	rootCmd.PersistentFlags().BoolVarP(&DryRun, "dryrun", "d", false, "Executes a dry run (bool)")
 	rootCmd.PersistentFlags().BoolVarP(&SizeIEC, "size-iec", "i", false, "Displays file sizes in IEC units (bool)")
+	cleanCmd.Flags().StringVar(&ExcludeFile, "excludeFile", "", "Specify a file containing Lambdas that must never be cleaned, even during a full scan")
 	cleanCmd.Flags().Int8VarP(&Retain, "count", "c", 1, "The number of versions to retain from $LATEST-(n)")
+	cleanCmd.Flags().StringSliceVar(&KeepDescription, "keep-description", nil, "Always retain the versions with a description matching the glob pattern, or the regular expression wrapped in slashes. Can be repeated")
+	cleanCmd.Flags().BoolVar(&ProtectCfn, "protect-cfn", false, "Skip the versions referenced by the AWS::Lambda::Version resources of CloudFormation and SAM stacks (bool)")
+	cleanCmd.Flags().BoolVar(&ProtectSfn, "protect-sfn", false, "Skip the versions referenced by the definitions of Step Functions state machines (bool)")
+	cleanCmd.Flags().BoolVar(&ProtectIntegrations, "protect-integrations", false, "Skip the versions with a version-specific resource-based policy or event invoke configuration (bool)")
+	cleanCmd.Flags().StringSliceVar(&StackNames, "stack-name", []string{}, "Clean the Lambdas of a CloudFormation stack instead of all the Lambdas of the region. The flag can be repeated")
+	cleanCmd.Flags().BoolVar(&NestedStacks, "nested-stacks", false, "Include the Lambdas of the nested stacks of the --stack-name stacks (bool)")
+	cleanCmd.Flags().BoolVar(&TagOverrides, "tag-overrides", false, "Apply the glc:retain, glc:skip, and glc:max-age tags of each Lambda. The custom list takes precedence over the tags (bool)")
+	cleanCmd.Flags().BoolVar(&ProtectCodeDeploy, "protect-codedeploy", false, "Skip the source and target versions of the CodeDeploy deployments in progress (bool)")
 	cleanCmd.Flags().BoolVarP(&SkipAliases, "skip-aliases", "s", false, "Skip trying to delete versions with aliases attached")
+	cleanCmd.Flags().BoolVar(&SkipUpdating, "skip-updating", false, "Skip the Lambdas that are being deployed, such as a Lambda in the Pending state or with an update in progress (bool)")
+	cleanCmd.Flags().StringVar(&UpdateGracePeriod, "update-grace-period", "", "Skip the Lambdas modified less than the age ago, such as 30m, 12h, or 1d")
+	cleanCmd.Flags().StringSliceVar(&PruneAliases, "prune-aliases", []string{}, "Delete the aliases with a name that matches a glob pattern, such as 'pr-*', before the versions are cleaned. The flag can be repeated")
+	cleanCmd.Flags().StringVar(&AliasOlderThan, "alias-older-than", "", "Only prune the aliases that point to a version older than the age, such as 72h, 30d, or 2w")
+	cleanCmd.Flags().Int8Var(&RetainBehindAlias, "retain-behind-alias", 0, "The number of versions immediately older than the version of each alias to retain so that the alias can be rolled back")
+	cleanCmd.Flags().IntVar(&Concurrency, "concurrency", cleaner.DefaultConcurrency, "The maximum number of Lambdas scanned or versions deleted at the same time")
+	cleanCmd.Flags().Float64Var(&RateLimit, "rate-limit", cleaner.DefaultRateLimit, "The maximum number of Lambdas scanned or versions deleted per second. The Lambda control plane API allows 15 requests per second for the account and region. Set to 0 to disable the limit")
+	cleanCmd.Flags().StringVar(&StateFile, "state-file", "", "Specify a file to record each completed deletion and the planned remainder of the clean-up")
+	cleanCmd.Flags().StringVar(&ResumeFile, "resume", "", "Specify a state file to resume an interrupted clean-up without rescanning the completed Lambdas")
+	cleanCmd.Flags().StringVar(&EndpointURL, "endpoint-url", "", "Specify a custom endpoint URL for the AWS APIs, such as the Lambda, CloudFormation, Step Functions, CodeDeploy, and S3 APIs. Overrides the AWS_ENDPOINT_URL_<SERVICE> and AWS_ENDPOINT_URL env variables")
+	cleanCmd.Flags().StringVar(&Target, "target", "", "Specify the name of a target of the configuration file to clean")
+	cleanCmd.Flags().BoolVar(&AllTargets, "all-targets", false, "Clean every target of the configuration file in sequence and display a combined report (bool)")
+	cleanCmd.Flags().BoolVar(&Verify, "verify", false, "Rescan the Lambdas after the clean-up to confirm the deleted versions are removed (bool)")
 
 	GlobalCliConfig.RegionFlag = &RegionFlag
 	GlobalCliConfig.ProfileFlag = &ProfileFlag
@@ -103,10 +188,34 @@ func Execute() {
 	err := rootCmd.Execute()
 	if err != nil {
 		log.Error(err)
+
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
 		os.Exit(1)
 	}
 }
 
+// exitError is an error that requires the CLI to exit with a specific exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// newInterruptedError returns the error used when the execution is interrupted by a SIGINT or SIGTERM signal.
+func newInterruptedError() error {
+	return &exitError{
+		code: ExitCodeInterrupted,
+		err:  cleaner.ErrInterrupted,
+	}
+}
+
 // createHTTPClient creates an HTTP client with TLS.
 func createHTTPClient() *http.Client {
 	// Setup client header to use TLS 1.2
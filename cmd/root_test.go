@@ -6,6 +6,7 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -374,6 +375,20 @@ func TestNoLambdas(t *testing.T) {
 	})
 }
 
+func TestInterruptedError(t *testing.T) {
+
+	err := fmt.Errorf("wrapped: %w", newInterruptedError())
+
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected an exitError to be returned but received %T", err)
+	}
+
+	if exitErr.code != ExitCodeInterrupted {
+		t.Errorf("expected the exit code to be %d but received %d", ExitCodeInterrupted, exitErr.code)
+	}
+}
+
 func TestCreateHTTPClient(t *testing.T) {
 
 	client := createHTTPClient()
@@ -0,0 +1,206 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// stepFunctionsMaxResults is the maximum number of items requested per page.
+	stepFunctionsMaxResults int32 = 1000
+)
+
+// stepFunctionsAPI is the subset of the AWS Step Functions API used to read the definitions of the state machines.
+type stepFunctionsAPI interface {
+	sfn.ListStateMachinesAPIClient
+	ListStateMachineVersions(ctx context.Context, params *sfn.ListStateMachineVersionsInput, optFns ...func(*sfn.Options)) (*sfn.ListStateMachineVersionsOutput, error)
+	DescribeStateMachine(ctx context.Context, params *sfn.DescribeStateMachineInput, optFns ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error)
+}
+
+// newStepFunctionsClient returns an AWS Step Functions API client that uses the AWS configuration.
+// The endpointURL of the --endpoint-url flag takes precedence. Otherwise, the endpoint is resolved by the AWS SDK, so the AWS_ENDPOINT_URL and AWS_ENDPOINT_URL_SFN env variables are honored.
+func newStepFunctionsClient(cfg aws.Config, endpointURL string) *sfn.Client {
+	return sfn.NewFromConfig(cfg, func(o *sfn.Options) {
+		o.APIOptions = append(o.APIOptions, middleware.AddUserAgentKeyValue("go-lambda-cleanup", VersionString))
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	})
+}
+
+// stateMachineVersions returns the ARNs of the published versions of a state machine. The AWS SDK does not provide a paginator for the operation.
+func stateMachineVersions(ctx context.Context, svc stepFunctionsAPI, stateMachineARN string) ([]string, error) {
+	var arns []string
+
+	input := &sfn.ListStateMachineVersionsInput{
+		StateMachineArn: aws.String(stateMachineARN),
+		MaxResults:      stepFunctionsMaxResults,
+	}
+
+	for {
+		output, err := svc.ListStateMachineVersions(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range output.StateMachineVersions {
+			arns = append(arns, aws.ToString(item.StateMachineVersionArn))
+		}
+
+		if aws.ToString(output.NextToken) == "" {
+			return arns, nil
+		}
+
+		input.NextToken = output.NextToken
+	}
+}
+
+// stateMachineLambdaVersions returns the Lambda versions referenced by the definitions of every state machine of the region and of their published versions.
+// A version is returned as the name of the Lambda qualified with the version, such as my-function:17. An error is returned if a state machine cannot be read.
+func stateMachineLambdaVersions(ctx context.Context, svc stepFunctionsAPI) ([]string, error) {
+	seen := make(map[string]bool)
+
+	p := sfn.NewListStateMachinesPaginator(svc, &sfn.ListStateMachinesInput{
+		MaxResults: stepFunctionsMaxResults,
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list the Step Functions state machines: %w", err)
+		}
+
+		for _, stateMachine := range page.StateMachines {
+			err = stateMachineReferences(ctx, svc, aws.ToString(stateMachine.StateMachineArn), seen)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	output := make([]string, 0, len(seen))
+	for version := range seen {
+		output = append(output, version)
+	}
+
+	sort.Strings(output)
+
+	return output, nil
+}
+
+// stateMachineReferences adds the Lambda versions referenced by the definitions of a state machine and of its published versions to the set of versions.
+func stateMachineReferences(ctx context.Context, svc stepFunctionsAPI, stateMachine string, seen map[string]bool) error {
+	versions, err := stateMachineVersions(ctx, svc, stateMachine)
+	if err != nil {
+		return fmt.Errorf("unable to list the versions of the state machine %s: %w", stateMachine, err)
+	}
+
+	for _, id := range append([]string{stateMachine}, versions...) {
+		output, err := svc.DescribeStateMachine(ctx, &sfn.DescribeStateMachineInput{
+			StateMachineArn: aws.String(id),
+		})
+		if err != nil {
+			return fmt.Errorf("unable to read the definition of the state machine %s: %w", id, err)
+		}
+
+		referenced, err := definitionLambdaVersions(aws.ToString(output.Definition))
+		if err != nil {
+			return fmt.Errorf("unable to parse the definition of the state machine %s: %w", id, err)
+		}
+
+		if len(referenced) > 0 {
+			log.Debug(fmt.Sprintf("State machine %s references %d Lambda versions", id, len(referenced)))
+		}
+
+		for _, version := range referenced {
+			seen[version] = true
+		}
+	}
+
+	return nil
+}
+
+// definitionLambdaVersions returns the Lambda versions referenced by the Resource and FunctionName fields of an Amazon States Language definition.
+// The states nested in Parallel and Map states are included. Aliases and unqualified functions are ignored.
+func definitionLambdaVersions(definition string) ([]string, error) {
+	var (
+		document any
+		versions []string
+	)
+
+	err := json.Unmarshal([]byte(definition), &document)
+	if err != nil {
+		return nil, err
+	}
+
+	var walk func(value any)
+
+	walk = func(value any) {
+		switch node := value.(type) {
+		case map[string]any:
+			for key, child := range node {
+				if field, ok := child.(string); ok && (key == "Resource" || key == "FunctionName") {
+					if version, ok := qualifiedLambdaVersion(field); ok {
+						versions = append(versions, version)
+					}
+
+					continue
+				}
+
+				walk(child)
+			}
+
+		case []any:
+			for _, child := range node {
+				walk(child)
+			}
+		}
+	}
+
+	walk(document)
+
+	sort.Strings(versions)
+
+	return versions, nil
+}
+
+// qualifiedLambdaVersion returns the name of a Lambda qualified with a version, such as my-function:17, from a function name, a partial ARN, or an ARN.
+// False is returned if the value is not a Lambda function or is not qualified with a version.
+func qualifiedLambdaVersion(value string) (string, bool) {
+	if arn.IsARN(value) {
+		parsed, err := arn.Parse(value)
+		if err != nil || parsed.Service != "lambda" {
+			return "", false
+		}
+
+		value = parsed.Resource
+	} else if _, resource, found := strings.Cut(value, ":function:"); found {
+		// A partial ARN such as 123456789012:function:my-function:17.
+		value = "function:" + resource
+	}
+
+	value = strings.TrimPrefix(value, "function:")
+
+	name, qualifier, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return "", false
+	}
+
+	if _, err := strconv.ParseUint(qualifier, 10, 64); err != nil {
+		return "", false
+	}
+
+	return name + ":" + qualifier, true
+}
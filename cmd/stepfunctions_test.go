@@ -0,0 +1,186 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sfn"
+	sfnTypes "github.com/aws/aws-sdk-go-v2/service/sfn/types"
+)
+
+const testDefinition = `{
+  "StartAt": "Validate",
+  "States": {
+    "Validate": {
+      "Type": "Task",
+      "Resource": "arn:aws:lambda:us-east-1:000000000000:function:validate:17",
+      "Next": "Process"
+    },
+    "Process": {
+      "Type": "Parallel",
+      "Branches": [
+        {
+          "StartAt": "Invoke",
+          "States": {
+            "Invoke": {
+              "Type": "Task",
+              "Resource": "arn:aws:states:::lambda:invoke",
+              "Parameters": {
+                "FunctionName": "000000000000:function:process:4",
+                "Payload.$": "$"
+              },
+              "End": true
+            }
+          }
+        },
+        {
+          "StartAt": "Notify",
+          "States": {
+            "Notify": {
+              "Type": "Task",
+              "Resource": "arn:aws:states:::lambda:invoke",
+              "Arguments": {
+                "FunctionName": "notify:live"
+              },
+              "End": true
+            }
+          }
+        }
+      ],
+      "End": true
+    }
+  }
+}`
+
+// fakeStepFunctions is an in-memory AWS Step Functions API. The versions of a state machine are returned one per page.
+type fakeStepFunctions struct {
+	stateMachines []string
+	versions      map[string][]string
+	definitions   map[string]string
+	versionPages  int
+	err           error
+}
+
+func (f *fakeStepFunctions) ListStateMachines(_ context.Context, _ *sfn.ListStateMachinesInput, _ ...func(*sfn.Options)) (*sfn.ListStateMachinesOutput, error) {
+	var output sfn.ListStateMachinesOutput
+
+	for _, stateMachine := range f.stateMachines {
+		output.StateMachines = append(output.StateMachines, sfnTypes.StateMachineListItem{StateMachineArn: aws.String(stateMachine)})
+	}
+
+	return &output, nil
+}
+
+func (f *fakeStepFunctions) ListStateMachineVersions(_ context.Context, params *sfn.ListStateMachineVersionsInput, _ ...func(*sfn.Options)) (*sfn.ListStateMachineVersionsOutput, error) {
+	f.versionPages++
+
+	versions := f.versions[*params.StateMachineArn]
+	if params.NextToken == nil || len(versions) == 0 {
+		// The first page is empty to ensure the next pages are requested.
+		return &sfn.ListStateMachineVersionsOutput{NextToken: aws.String("0")}, nil
+	}
+
+	i, _ := strconv.Atoi(*params.NextToken)
+	output := &sfn.ListStateMachineVersionsOutput{
+		StateMachineVersions: []sfnTypes.StateMachineVersionListItem{{StateMachineVersionArn: aws.String(versions[i])}},
+	}
+
+	if i+1 < len(versions) {
+		output.NextToken = aws.String(strconv.Itoa(i + 1))
+	}
+
+	return output, nil
+}
+
+func (f *fakeStepFunctions) DescribeStateMachine(_ context.Context, params *sfn.DescribeStateMachineInput, _ ...func(*sfn.Options)) (*sfn.DescribeStateMachineOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &sfn.DescribeStateMachineOutput{Definition: aws.String(f.definitions[*params.StateMachineArn])}, nil
+}
+
+func TestDefinitionLambdaVersions(t *testing.T) {
+
+	got, err := definitionLambdaVersions(testDefinition)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"process:4", "validate:17"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	_, err = definitionLambdaVersions("{")
+	if err == nil {
+		t.Errorf("expected an error to be returned for an invalid definition but received %v", err)
+	}
+
+}
+
+func TestQualifiedLambdaVersion(t *testing.T) {
+
+	tests := map[string]string{
+		"arn:aws:lambda:us-east-1:000000000000:function:foo:17": "foo:17",
+		"000000000000:function:foo:3":                           "foo:3",
+		"foo:9":                                                 "foo:9",
+		"arn:aws:lambda:us-east-1:000000000000:function:foo":    "",
+		"foo:live":                       "",
+		"foo":                            "",
+		"arn:aws:states:::lambda:invoke": "",
+		"foo:$LATEST":                    "",
+	}
+
+	for value, want := range tests {
+		got, ok := qualifiedLambdaVersion(value)
+		if got != want || ok != (want != "") {
+			t.Errorf("expected %q to return %q but received %q", value, want, got)
+		}
+	}
+
+}
+
+func TestStateMachineLambdaVersions(t *testing.T) {
+
+	svc := &fakeStepFunctions{
+		stateMachines: []string{"arn:aws:states:us-east-1:000000000000:stateMachine:orders"},
+		versions: map[string][]string{
+			"arn:aws:states:us-east-1:000000000000:stateMachine:orders": {"arn:aws:states:us-east-1:000000000000:stateMachine:orders:1"},
+		},
+		definitions: map[string]string{
+			"arn:aws:states:us-east-1:000000000000:stateMachine:orders":   testDefinition,
+			"arn:aws:states:us-east-1:000000000000:stateMachine:orders:1": strings.ReplaceAll(testDefinition, "validate:17", "validate:12"),
+		},
+	}
+
+	got, err := stateMachineLambdaVersions(context.Background(), svc)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"process:4", "validate:12", "validate:17"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	if svc.versionPages != 2 {
+		t.Errorf("expected the versions to be read from 2 pages but received %d", svc.versionPages)
+	}
+
+	svc.err = errors.New("AccessDeniedException")
+
+	_, err = stateMachineLambdaVersions(context.Background(), svc)
+	if err == nil || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Errorf("expected the API error to be returned but received %v", err)
+	}
+
+}
@@ -0,0 +1,217 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner"
+	log "github.com/sirupsen/logrus"
+)
+
+// targetRun is a single execution of the clean-up for a named target and region.
+type targetRun struct {
+	target string
+	config cliConfig
+}
+
+// targetResult is the outcome of a targetRun.
+type targetResult struct {
+	run     targetRun
+	summary cleaner.Summary
+	err     error
+}
+
+/*
+planTargetRuns returns the clean-up executions requested by the --target and --all-targets flags. A target with multiple regions results in one execution per region.
+The values of a target take precedence over the configuration file defaults and the GLC_* env variables, but the flags provided on the command line take precedence over the target.
+If no target is requested, a single execution with the provided configuration is returned.
+*/
+func planTargetRuns(config cliConfig, target string, allTargets bool, layers configLayers) ([]targetRun, error) {
+	if target == "" && !allTargets {
+		return []targetRun{{config: config}}, nil
+	}
+
+	if target != "" && allTargets {
+		return nil, errors.New("the --target and --all-targets flags cannot be used together")
+	}
+
+	if len(layers.targets) == 0 {
+		return nil, errors.New("no targets are defined in the configuration file. Please add a targets section to the configuration file")
+	}
+
+	var names []string
+
+	if allTargets {
+		for name := range layers.targets {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	} else {
+		if _, ok := layers.targets[target]; !ok {
+			return nil, fmt.Errorf("the target %s is not defined in the configuration file %s", target, layers.path)
+		}
+
+		names = []string{target}
+	}
+
+	var runs []targetRun
+
+	for _, name := range names {
+		t := layers.targets[name]
+
+		for _, pattern := range slices.Concat(t.Include, t.Exclude) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("the target %s contains an invalid pattern %s: %w", name, pattern, err)
+			}
+		}
+
+		if t.OlderThan != "" {
+			if _, err := internal.ParseOlderThan(t.OlderThan); err != nil {
+				return nil, fmt.Errorf("the target %s contains an invalid older-than value %s: %w", name, t.OlderThan, err)
+			}
+		}
+
+		regions := t.Regions
+		if t.Region != "" {
+			regions = append([]string{t.Region}, regions...)
+		}
+
+		if len(regions) == 0 || layers.sources["region"] == sourceFlag {
+			regions = []string{*config.RegionFlag}
+		}
+
+		for _, region := range regions {
+			runs = append(runs, targetRun{
+				target: name,
+				config: applyTarget(config, t, region, layers.sources),
+			})
+		}
+	}
+
+	if len(runs) > 1 && (config.StateFile != "" || config.ResumeFile != "") {
+		return nil, errors.New("the --state-file and --resume flags cannot be used with more than one target or region")
+	}
+
+	return runs, nil
+}
+
+// applyTarget returns a copy of the configuration with the values of the target applied to the values that were not provided with a flag.
+func applyTarget(config cliConfig, t internal.Target, region string, sources map[string]string) cliConfig {
+	// The region is validated and updated in place by runClean, so each run receives its own copy.
+	config.RegionFlag = aws.String(region)
+
+	if t.Profile != "" && sources["profile"] != sourceFlag {
+		config.ProfileFlag = aws.String(t.Profile)
+	}
+
+	if t.Count != nil && sources["count"] != sourceFlag {
+		config.Retain = aws.Int8(*t.Count)
+	}
+
+	if t.SkipAliases != nil && sources["skip-aliases"] != sourceFlag {
+		config.SkipAliases = aws.Bool(*t.SkipAliases)
+	}
+
+	if t.KeepDescription != nil && sources["keep-description"] != sourceFlag {
+		config.KeepDescription = t.KeepDescription
+	}
+
+	// The older-than value is validated by planTargetRuns.
+	config.OlderThan, _ = internal.ParseOlderThan(t.OlderThan)
+
+	config.RoleARN = t.RoleARN
+	config.Include = t.Include
+	config.Exclude = t.Exclude
+
+	return config
+}
+
+// runTargets executes the clean-up of each target run in sequence and reports the combined totals.
+// A failed run does not prevent the next runs from executing. The execution stops if it is interrupted.
+func runTargets(ctx context.Context, runs []targetRun) error {
+	var (
+		results []targetResult
+		errs    []error
+	)
+
+	for _, run := range runs {
+		log.Info("******** TARGET " + run.target + " ********")
+
+		summary, err := runClean(ctx, run.config)
+		results = append(results, targetResult{
+			run:     run,
+			summary: summary,
+			err:     err,
+		})
+
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			reportTargets(results)
+
+			return err
+		}
+
+		if err != nil {
+			log.Error(err)
+
+			errs = append(errs, fmt.Errorf("the target %s in %s failed: %w", run.target, *run.config.RegionFlag, err))
+		}
+	}
+
+	reportTargets(results)
+
+	return errors.Join(errs...)
+}
+
+// reportTargets logs the totals of each target run and the combined totals of all the runs.
+func reportTargets(results []targetResult) {
+	var (
+		versions int
+		size     int64
+	)
+
+	log.Info("******** COMBINED REPORT ********")
+
+	for _, result := range results {
+		config := result.run.config
+		name := fmt.Sprintf("%s (%s)", result.run.target, *config.RegionFlag)
+
+		count, freed := result.summary.Deleted, result.summary.Freed
+		if *config.DryRun {
+			count, freed = result.summary.Planned, result.summary.PlannedSize
+		}
+
+		versions = versions + count
+		size = size + freed
+
+		line := fmt.Sprintf("%s: %d Lambdas, %d versions, %s", name, result.summary.Lambdas, count, cleaner.CalculateFileSize(uint64(freed), cleaner.Options{SizeIEC: *config.SizeIEC}))
+		if result.err != nil {
+			log.Warn(line + ". Failed: " + result.err.Error())
+
+			continue
+		}
+
+		log.Info(line)
+	}
+
+	last := results[len(results)-1].run.config
+	if *last.DryRun {
+		log.Infof("%d unique versions will be removed in an actual execution.", versions)
+		log.Info(cleaner.CalculateFileSize(uint64(size), cleaner.Options{SizeIEC: *last.SizeIEC}) + " of storage space will be removed in an actual execution.")
+
+		return
+	}
+
+	log.Info("Total versions removed: ", versions)
+	log.Info("Total space freed up: ", cleaner.CalculateFileSize(uint64(size), cleaner.Options{SizeIEC: *last.SizeIEC}))
+}
@@ -0,0 +1,164 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+)
+
+// newTargetsConfig returns a CLI configuration and the configuration layers of the tests/config.yaml targets.
+func newTargetsConfig() (cliConfig, configLayers) {
+	config := cliConfig{
+		RegionFlag:  aws.String(""),
+		ProfileFlag: aws.String(""),
+		DryRun:      aws.Bool(true),
+		SizeIEC:     aws.Bool(false),
+		SkipAliases: aws.Bool(false),
+		Retain:      aws.Int8(1),
+	}
+
+	layers := configLayers{
+		path:    "../tests/config.yaml",
+		sources: map[string]string{},
+		targets: map[string]internal.Target{
+			"prod-eu": {
+				Profile:         "prod",
+				Regions:         []string{"eu-west-1", "eu-central-1"},
+				RoleARN:         "arn:aws:iam::123456789012:role/glc",
+				Count:           aws.Int8(3),
+				SkipAliases:     aws.Bool(true),
+				Include:         []string{"api-*"},
+				OlderThan:       "30d",
+				KeepDescription: []string{"release-*"},
+			},
+			"staging": {
+				Profile: "staging",
+				Region:  "us-east-1",
+			},
+		},
+	}
+
+	return config, layers
+}
+
+func TestPlanTargetRuns(t *testing.T) {
+
+	config, layers := newTargetsConfig()
+
+	runs, err := planTargetRuns(config, "", false, layers)
+	if err != nil || len(runs) != 1 || runs[0].target != "" {
+		t.Fatalf("expected a single run without a target but received %v %v", runs, err)
+	}
+
+	runs, err = planTargetRuns(config, "prod-eu", false, layers)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(runs) != 2 || *runs[0].config.RegionFlag != "eu-west-1" || *runs[1].config.RegionFlag != "eu-central-1" {
+		t.Fatalf("expected a run for each region of prod-eu but received %v", runs)
+	}
+
+	prod := runs[0].config
+	if *prod.ProfileFlag != "prod" || *prod.Retain != 3 || !*prod.SkipAliases || prod.RoleARN == "" || len(prod.Include) != 1 {
+		t.Errorf("expected the values of prod-eu to be applied but received %+v", prod)
+	}
+
+	if prod.OlderThan != 30*24*time.Hour || !slices.Equal(prod.KeepDescription, []string{"release-*"}) {
+		t.Errorf("expected the older-than and keep-description values of prod-eu to be applied but received %s and %v", prod.OlderThan, prod.KeepDescription)
+	}
+
+	if runs[0].config.RegionFlag == runs[1].config.RegionFlag {
+		t.Errorf("expected each run to receive its own region")
+	}
+
+	runs, err = planTargetRuns(config, "", true, layers)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(runs) != 3 || runs[2].target != "staging" || *runs[2].config.Retain != 1 {
+		t.Errorf("expected 3 runs ending with staging but received %v", runs)
+	}
+
+	if staging := runs[2].config; staging.OlderThan != 0 || staging.KeepDescription != nil {
+		t.Errorf("expected the older-than and keep-description values of prod-eu not to apply to staging but received %s and %v", staging.OlderThan, staging.KeepDescription)
+	}
+
+}
+
+func TestPlanTargetRunsFlagPrecedence(t *testing.T) {
+
+	config, layers := newTargetsConfig()
+	config.RegionFlag = aws.String("us-west-2")
+	config.Retain = aws.Int8(5)
+	config.KeepDescription = []string{"stable-*"}
+	layers.sources = map[string]string{"region": sourceFlag, "count": sourceFlag, "keep-description": sourceFlag, "profile": sourceFile}
+
+	runs, err := planTargetRuns(config, "prod-eu", false, layers)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(runs) != 1 || *runs[0].config.RegionFlag != "us-west-2" {
+		t.Fatalf("expected the region flag to replace the regions of the target but received %v", runs)
+	}
+
+	if *runs[0].config.Retain != 5 || *runs[0].config.ProfileFlag != "prod" {
+		t.Errorf("expected the count flag and the target profile but received %d and %s", *runs[0].config.Retain, *runs[0].config.ProfileFlag)
+	}
+
+	if !slices.Equal(runs[0].config.KeepDescription, []string{"stable-*"}) {
+		t.Errorf("expected the keep-description flag to take precedence over the target but received %v", runs[0].config.KeepDescription)
+	}
+
+}
+
+func TestPlanTargetRunsErrors(t *testing.T) {
+
+	config, layers := newTargetsConfig()
+
+	_, err := planTargetRuns(config, "prod-eu", true, layers)
+	if err == nil {
+		t.Errorf("expected an error for --target and --all-targets but received %v", err)
+	}
+
+	_, err = planTargetRuns(config, "missing", false, layers)
+	if err == nil {
+		t.Errorf("expected an error for an unknown target but received %v", err)
+	}
+
+	_, err = planTargetRuns(config, "", true, configLayers{})
+	if err == nil {
+		t.Errorf("expected an error without targets but received %v", err)
+	}
+
+	stateConfig := config
+	stateConfig.StateFile = "state.json"
+
+	_, err = planTargetRuns(stateConfig, "prod-eu", false, layers)
+	if err == nil {
+		t.Errorf("expected an error for a state file with multiple regions but received %v", err)
+	}
+
+	layers.targets["invalid"] = internal.Target{Exclude: []string{"api-["}}
+
+	_, err = planTargetRuns(config, "invalid", false, layers)
+	if err == nil {
+		t.Errorf("expected an error for an invalid pattern but received %v", err)
+	}
+
+	layers.targets["invalid"] = internal.Target{OlderThan: "30"}
+
+	_, err = planTargetRuns(config, "invalid", false, layers)
+	if err == nil {
+		t.Errorf("expected an error for an invalid older-than value but received %v", err)
+	}
+
+}
@@ -3,19 +3,53 @@
 
 package cmd
 
-import "time"
+import (
+	"time"
+
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+)
 
 type cliConfig struct {
-	ProfileFlag       *string
-	CredentialsFile   *bool
-	RegionFlag        *string
-	Retain            *int8
-	Verbose           *bool
-	DryRun            *bool
-	LambdaListFile    *string
-	MoreLambdaDetails *bool
-	SizeIEC           *bool
-	SkipAliases       *bool
+	ProfileFlag         *string
+	CredentialsFile     *bool
+	RegionFlag          *string
+	Retain              *int8
+	Verbose             *bool
+	DryRun              *bool
+	LambdaListFile      *string
+	MoreLambdaDetails   *bool
+	SizeIEC             *bool
+	SkipAliases         *bool
+	Verify              bool
+	Concurrency         int
+	RateLimit           float64
+	StateFile           string
+	ResumeFile          string
+	EndpointURL         string
+	RoleARN             string
+	Include             []string
+	Exclude             []string
+	ExcludeFile         string
+	Protected           []string
+	ListColumn          string
+	KeepDescription     []string
+	ProtectCfn          bool
+	ProtectSfn          bool
+	ProtectIntegrations bool
+	TagOverrides        bool
+	StackNames          []string
+	NestedStacks        bool
+	RetainBehindAlias   int8
+	PruneAliases        []string
+	AliasOlderThan      time.Duration
+	SkipUpdating        bool
+	UpdateGracePeriod   time.Duration
+	ProtectCodeDeploy   bool
+	ProtectedVersions   []string
+	// OlderThan limits the deletion to the versions last modified more than the duration ago. It is only set by the older-than value of a target.
+	OlderThan time.Duration
+	// CustomList is the custom list read from the standard input before the runs, as the standard input can only be read once.
+	CustomList []internal.LambdaEntry
 }
 
 // Github Release Structure (v3).
@@ -0,0 +1,134 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// CheckExists indicates that the validate command should confirm each listed Lambda exists in the region.
+	CheckExists bool
+	// PrintSchema indicates that the validate command should print the JSON Schema of the list format.
+	PrintSchema bool
+)
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().BoolVar(&CheckExists, "check-exists", false, "Confirm each listed Lambda exists in the region (bool)")
+	validateCmd.Flags().BoolVar(&PrintSchema, "schema", false, "Print the JSON Schema of the list format (bool)")
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file>",
+	Short: "Validates a custom list or an exclude file",
+	Long:  `Validates a custom list or an exclude file and reports each problem with its line and column. Use --check-exists to confirm each listed Lambda exists in the region.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+
+		if PrintSchema {
+			_, err := out.Write(internal.LambdaListSchema)
+
+			return err
+		}
+
+		if len(args) == 0 {
+			return errors.New("missing file argument. Please provide the path of the file to validate")
+		}
+
+		file := args[0]
+
+		// The problems found are already reported, so the usage is not displayed.
+		cmd.SilenceUsage = true
+
+		entries, problems, err := internal.ValidateLambdaList(file, ListColumn)
+		if err != nil {
+			return err
+		}
+
+		if len(problems) == 0 && CheckExists {
+			ctx, cancel := interruptibleContext(context.Background())
+			defer cancel()
+
+			config := GlobalCliConfig
+
+			cfg, err := newAWSConfig(ctx, &config)
+			if err != nil {
+				return err
+			}
+
+			svc, err := newLambdaClient(cfg, config)
+			if err != nil {
+				return err
+			}
+
+			problems, err = checkListedLambdas(ctx, svc, entries, *config.RegionFlag)
+			if err != nil {
+				return err
+			}
+		}
+
+		return reportValidation(out, file, entries, problems)
+	},
+}
+
+// lambdaGetter is the subset of the AWS Lambda API used to confirm a Lambda exists.
+type lambdaGetter interface {
+	GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
+}
+
+// checkListedLambdas confirms each entry that applies to the region names an existing Lambda. A missing Lambda is reported at the position of its entry.
+func checkListedLambdas(ctx context.Context, svc lambdaGetter, entries []internal.ListedLambda, region string) ([]internal.ValidationError, error) {
+	var problems []internal.ValidationError
+
+	for _, entry := range entries {
+		if entryRegion := entry.FunctionRegion(); entryRegion != "" && entryRegion != region {
+			continue
+		}
+
+		_, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{
+			FunctionName: aws.String(entry.Name),
+		})
+		if err != nil {
+			var rnf *types.ResourceNotFoundException
+			if !errors.As(err, &rnf) {
+				return problems, fmt.Errorf("unable to confirm %s exists: %w", entry.Name, err)
+			}
+
+			problems = append(problems, internal.ValidationError{
+				Line:    entry.Line,
+				Column:  entry.Column,
+				Message: fmt.Sprintf("the Lambda %s does not exist in %s", strconv.Quote(entry.FunctionName()), region),
+			})
+		}
+	}
+
+	return problems, nil
+}
+
+// reportValidation writes each problem prefixed with the file name and its position. An error is returned if any problem was found.
+func reportValidation(out io.Writer, file string, entries []internal.ListedLambda, problems []internal.ValidationError) error {
+	for _, problem := range problems {
+		fmt.Fprintf(out, "%s:%s\n", file, problem.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found in %s", len(problems), file)
+	}
+
+	fmt.Fprintf(out, "%s is valid. %d Lambdas listed\n", file, len(entries))
+
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner/cleanertest"
+)
+
+func TestValidateCmd(t *testing.T) {
+
+	var buf bytes.Buffer
+	validateCmd.SetOut(&buf)
+
+	t.Cleanup(func() {
+		validateCmd.SetOut(nil)
+	})
+
+	err := validateCmd.RunE(validateCmd, []string{"../tests/test-v2.yaml"})
+	if err != nil || !strings.Contains(buf.String(), "is valid. 4 Lambdas listed") {
+		t.Fatalf("expected the file to be valid but received %s %v", buf.String(), err)
+	}
+
+	buf.Reset()
+
+	err = validateCmd.RunE(validateCmd, []string{"../tests/invalid-list.yaml"})
+	if err == nil {
+		t.Fatalf("expected an error to be returned but received %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "../tests/invalid-list.yaml:6:11: invalid function name") {
+		t.Errorf("expected the problems to be reported with their position but received %s", buf.String())
+	}
+
+	err = validateCmd.RunE(validateCmd, []string{})
+	if err == nil {
+		t.Errorf("expected an error without a file but received %v", err)
+	}
+
+}
+
+func TestCheckListedLambdas(t *testing.T) {
+
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("putControls", 100, 1)
+
+	entries := []internal.ListedLambda{
+		{LambdaEntry: internal.LambdaEntry{Name: "putControls"}, Line: 5, Column: 5},
+		{LambdaEntry: internal.LambdaEntry{Name: "stopEC2-instances"}, Line: 6, Column: 5},
+		{LambdaEntry: internal.LambdaEntry{Name: "rotateKeys", Region: "eu-west-1"}, Line: 7, Column: 5},
+	}
+
+	problems, err := checkListedLambdas(context.Background(), svc, entries, "us-east-1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(problems) != 1 || problems[0].Line != 6 || !strings.Contains(problems[0].Message, "stopEC2-instances") {
+		t.Errorf("expected stopEC2-instances to be reported as missing but received %v", problems)
+	}
+
+	if svc.Calls(cleanertest.OperationGetFunction) != 2 {
+		t.Errorf("expected the Lambda limited to another region to be skipped but received %d calls", svc.Calls(cleanertest.OperationGetFunction))
+	}
+
+}
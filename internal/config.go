@@ -4,9 +4,11 @@
 package internal
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -14,102 +16,183 @@ import (
 )
 
 // GenerateLambdaDeleteList is a function that takes a file path as input and returns a list of Lambdas to be deleted.
+// The per-Lambda settings of the entries are ignored. Use GenerateLambdaDeleteEntries to retrieve them.
 func GenerateLambdaDeleteList(filePath string) ([]string, error) {
-	var (
-		deleteListYaml CustomDeleteListYaml
-		deleteListJson CustomDeleteListJson
-		output         []string
-	)
+	entries, err := GenerateLambdaDeleteEntries(filePath)
 
+	output := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		output = append(output, entry.Name)
+	}
+
+	return output, err
+}
+
+// GenerateLambdaDeleteEntries is a function that takes a file path as input and returns the entries of the Lambdas to be deleted.
+// An entry is either the name of a Lambda or an object with per-Lambda settings. Each entry is validated.
+// The names of a CSV file are read from its first column. Use ReadLambdaList to select another column or to read the list from another source.
+func GenerateLambdaDeleteEntries(filePath string) ([]LambdaEntry, error) {
 	fileType, err := determineFileType(filePath)
 	if err != nil {
-		return []string{}, err
+		return []LambdaEntry{}, err
 	}
 
-	if fileType == "json" {
-		deleteListJson, err = readConfigFileJson(filePath)
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return []LambdaEntry{}, errors.New("unable to read the input file")
+	}
+
+	return ParseLambdaList(content, fileType, "")
+}
+
+// ParseLambdaList returns the validated entries of a Lambda list of the provided type: json, yaml, txt, or csv.
+// The column selects the column of a CSV list by its header name or by its 1-based index. The first column is used if empty.
+func ParseLambdaList(content []byte, fileType, column string) ([]LambdaEntry, error) {
+	var output []LambdaEntry
+
+	switch fileType {
+	case "json":
+		list, err := decodeJsonList(content)
 		if err != nil {
-			return deleteListJson.Lambdas, err
+			return []LambdaEntry{}, err
 		}
 
-		output = deleteListJson.Lambdas
-	}
+		output = list.Lambdas
+
+	case "yaml":
+		list, err := decodeYamlList(content)
+		if err != nil {
+			return []LambdaEntry{}, err
+		}
 
-	if fileType == "yaml" {
-		deleteListYaml, err = readConfigFileYaml(filePath)
+		output = list.Lambdas
+
+	case "txt", "csv":
+		listed, err := parsePlainList(content, fileType, column)
 		if err != nil {
-			return deleteListYaml.Lambdas, err
+			return []LambdaEntry{}, err
 		}
 
-		output = deleteListYaml.Lambdas
+		for _, item := range listed {
+			output = append(output, item.LambdaEntry)
+		}
+
+	default:
+		return []LambdaEntry{}, fmt.Errorf("unsupported list type %s", fileType)
 	}
 
-	return output, err
+	for _, entry := range output {
+		if err := entry.Validate(); err != nil {
+			return []LambdaEntry{}, err
+		}
+	}
+
+	return output, nil
 }
 
 // readConfigFileYaml is a function that takes a file path as input and returns a list of Lambdas to be deleted. A YAML file is expected.
 func readConfigFileYaml(file string) (CustomDeleteListYaml, error) {
-	var (
-		list CustomDeleteListYaml
-	)
-
 	fileContent, err := os.ReadFile(file)
 	if err != nil {
-		return list, errors.New("unable to read the input file")
+		return CustomDeleteListYaml{}, errors.New("unable to read the input file")
 	}
 
-	dc := yaml.NewDecoder(strings.NewReader(string(fileContent)))
+	return decodeYamlList(fileContent)
+}
+
+// decodeYamlList decodes a YAML list of Lambdas. Unknown fields are rejected.
+func decodeYamlList(content []byte) (CustomDeleteListYaml, error) {
+	var list CustomDeleteListYaml
+
+	dc := yaml.NewDecoder(bytes.NewReader(content))
 	dc.KnownFields(true)
 
 	if err := dc.Decode(&list); err != nil {
 		return list, fmt.Errorf("unable to decode the YAML file. Ensure the file is in the correct format and that all fields are correct. %s", err.Error())
 	}
 
-	return list, err
+	return list, nil
 }
 
 // readConfigFileJson is a function that takes a file path as input and returns a list of Lambdas to be deleted. A JSON file is expected.
 func readConfigFileJson(file string) (CustomDeleteListJson, error) {
-	var (
-		list CustomDeleteListJson
-	)
-
 	fileContent, err := os.ReadFile(file)
 	if err != nil {
-		return list, errors.New("unable to read the input file")
+		return CustomDeleteListJson{}, errors.New("unable to read the input file")
 	}
 
-	err = json.Unmarshal(fileContent, &list)
+	return decodeJsonList(fileContent)
+}
+
+// decodeJsonList decodes a JSON list of Lambdas. A top-level array, such as the output of aws lambda list-functions, is decoded as the entries of the list.
+func decodeJsonList(content []byte) (CustomDeleteListJson, error) {
+	var list CustomDeleteListJson
+
+	target := any(&list)
+	if trimmed := bytes.TrimSpace(content); len(trimmed) > 0 && trimmed[0] == '[' {
+		target = &list.Lambdas
+	}
+
+	err := json.Unmarshal(content, target)
 	if err != nil {
-		return list, errors.New("unable to unmarshall the json file")
+		return list, fmt.Errorf("unable to unmarshall the json file. Use glc validate to locate the error. %s", err.Error())
 	}
 
-	return list, err
+	return list, nil
 }
 
-// determineFileType validates the existence of an input file and ensures its prefix is json | yaml | yml.
+// determineFileType validates the existence of an input file and ensures its suffix is json | yaml | yml | txt | csv.
 func determineFileType(file string) (string, error) {
 	f, err := os.Stat(file)
 	if err != nil {
 		return "none", errors.New("unable to read the input file")
 	}
 
-	var fileType string
+	fileType := fileTypeOf(f.Name())
+	if fileType == "none" {
+		err = errors.New("invalid file type provided. Must be of type json, yaml, yml, txt, or csv")
+	}
+
+	return fileType, err
+}
 
+// fileTypeOf returns the list type matching the suffix of a file name, or none if the suffix is not supported.
+func fileTypeOf(name string) string {
 	switch {
-	case strings.HasSuffix(f.Name(), "yaml"):
-		fileType = "yaml"
+	case strings.HasSuffix(name, "yaml"), strings.HasSuffix(name, "yml"):
+		return "yaml"
+
+	case strings.HasSuffix(name, "json"):
+		return "json"
 
-	case strings.HasSuffix(f.Name(), "json"):
-		fileType = "json"
+	case strings.HasSuffix(name, ".txt"):
+		return "txt"
 
-	case strings.HasSuffix(f.Name(), "yml"):
-		fileType = "yaml"
+	case strings.HasSuffix(name, ".csv"):
+		return "csv"
 
 	default:
-		fileType = "none"
-		err = errors.New("invalid file type provided. Must be of type json, yaml or yml")
+		return "none"
 	}
+}
 
-	return fileType, err
+// ReadCLIConfigFile is a function that takes a file path as input and returns the content of a glc configuration file. A YAML file is expected.
+// The settings are keyed by the name of the CLI flag they provide a default value for. Unknown fields in a target are rejected.
+func ReadCLIConfigFile(file string) (CLIConfigFile, error) {
+	var config CLIConfigFile
+
+	fileContent, err := os.ReadFile(file)
+	if err != nil {
+		return config, fmt.Errorf("unable to read the configuration file %s", file)
+	}
+
+	dc := yaml.NewDecoder(strings.NewReader(string(fileContent)))
+	dc.KnownFields(true)
+
+	err = dc.Decode(&config)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("unable to decode the configuration file %s. Ensure the file is in the correct format. %s", file, err.Error())
+	}
+
+	return config, nil
 }
@@ -5,6 +5,7 @@ package internal
 
 import (
 	"testing"
+	"time"
 )
 
 func TestFileNotFound(t *testing.T) {
@@ -178,3 +179,117 @@ func TestGenerateLambdaDeleteListYml(t *testing.T) {
 		}
 	}
 }
+
+func TestReadCLIConfigFile(t *testing.T) {
+
+	got, err := ReadCLIConfigFile("../tests/config.yaml")
+	if err != nil {
+		t.Fatalf("Failed to read the configuration file. Expected no error but received %v", err)
+	}
+
+	if got.Settings["region"] != "us-west-2" || got.Settings["count"] != 2 || got.Settings["skip-aliases"] != true {
+		t.Fatalf("Failed to read the configuration file. Expected the settings of the file but received %v", got)
+	}
+
+	target, ok := got.Targets["prod-eu"]
+	if !ok || len(target.Regions) != 2 || *target.Count != 3 || target.RoleARN != "arn:aws:iam::123456789012:role/glc" || target.OlderThan != "30d" || len(target.KeepDescription) != 1 {
+		t.Fatalf("Failed to read the configuration file. Expected the prod-eu target but received %v", got.Targets)
+	}
+
+	if _, ok := got.Settings["targets"]; ok {
+		t.Fatalf("Failed to read the configuration file. Expected the targets to be excluded from the settings but received %v", got.Settings)
+	}
+
+	_, err = ReadCLIConfigFile("../tests/invalid-target-config.yaml")
+	if err == nil {
+		t.Fatalf("Failed to read the configuration file. Expected error for an unknown target field but received %v", err)
+	}
+
+	_, err = ReadCLIConfigFile("../tests/invalid-config.yaml")
+	if err == nil {
+		t.Fatalf("Failed to read the configuration file. Expected error but received %v", err)
+	}
+
+	_, err = ReadCLIConfigFile("missing.yaml")
+	if err == nil {
+		t.Fatalf("Failed to read the configuration file. Expected error but received %v", err)
+	}
+}
+
+func TestGenerateLambdaDeleteEntries(t *testing.T) {
+
+	for _, file := range []string{"../tests/test-v2.yaml", "../tests/test-v2.json"} {
+		got, err := GenerateLambdaDeleteEntries(file)
+		if err != nil || len(got) != 4 {
+			t.Fatalf("Failed to read %s. Expected 4 entries but received %d %v", file, len(got), err)
+		}
+
+		if got[0].Name != "stopEC2-instances" || got[0].HasOverrides() {
+			t.Errorf("Failed to read %s. Expected a plain name but received %+v", file, got[0])
+		}
+
+		if got[1].Name != "putControls" || *got[1].Retain != 3 || got[1].OlderThan != "30d" || !*got[1].SkipAliases {
+			t.Errorf("Failed to read %s. Expected the settings of putControls but received %+v", file, got[1])
+		}
+
+		if got[2].FunctionName() != "sendReports" || got[2].FunctionRegion() != "eu-west-1" {
+			t.Errorf("Failed to read %s. Expected sendReports in eu-west-1 but received %s in %s", file, got[2].FunctionName(), got[2].FunctionRegion())
+		}
+
+		if got[3].FunctionRegion() != "us-west-2" {
+			t.Errorf("Failed to read %s. Expected rotateKeys in us-west-2 but received %s", file, got[3].FunctionRegion())
+		}
+	}
+
+	names, err := GenerateLambdaDeleteList("../tests/test-v2.yaml")
+	if err != nil || len(names) != 4 || names[1] != "putControls" {
+		t.Errorf("Failed to read the names of the entries. Expected 4 names but received %v %v", names, err)
+	}
+
+	_, err = GenerateLambdaDeleteEntries("../tests/invalid-v2.yaml")
+	if err == nil {
+		t.Errorf("An error was expected for an unknown field but received %v", err)
+	}
+}
+
+func TestLambdaEntryValidate(t *testing.T) {
+
+	retain := int8(-1)
+
+	invalid := []LambdaEntry{
+		{},
+		{Name: "func1", Retain: &retain},
+		{Name: "func1", OlderThan: "soon"},
+		{Name: "func1", OlderThan: "0d"},
+		{Name: "arn:aws:lambda:us-east-1:123456789012:function:func1:3"},
+		{Name: "arn:aws:s3:::bucket"},
+		{Name: "arn:aws:lambda:us-east-1:123456789012:function:func1", Region: "eu-west-1"},
+	}
+
+	for _, entry := range invalid {
+		if err := entry.Validate(); err == nil {
+			t.Errorf("An error was expected for %+v but received %v", entry, err)
+		}
+	}
+
+	valid := LambdaEntry{Name: "arn:aws:lambda:us-east-1:123456789012:function:func1", OlderThan: "2w"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected no error for %+v but received %v", valid, err)
+	}
+}
+
+func TestParseOlderThan(t *testing.T) {
+
+	want := map[string]time.Duration{
+		"72h": 72 * time.Hour,
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	}
+
+	for value, duration := range want {
+		got, err := ParseOlderThan(value)
+		if err != nil || got != duration {
+			t.Errorf("Failed to parse %s. Expected %s but received %s %v", value, duration, got, err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// lambdaEntryFields are the fields accepted in the object form of a LambdaEntry.
+var lambdaEntryFields = []string{"name", "retain", "olderThan", "skipAliases", "region"}
+
+// lambdaEntryObject is the object form of a LambdaEntry. It prevents the custom unmarshallers from calling themselves.
+type lambdaEntryObject LambdaEntry
+
+// UnmarshalYAML accepts the name of a Lambda or an object with per-Lambda settings. Unknown fields are rejected.
+func (e *LambdaEntry) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		return value.Decode(&e.Name)
+	}
+
+	if value.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: a Lambda entry must be a name or an object", value.Line)
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		key := value.Content[i]
+		if !slices.Contains(lambdaEntryFields, key.Value) {
+			return fmt.Errorf("line %d: field %s not found in a Lambda entry", key.Line, key.Value)
+		}
+	}
+
+	return value.Decode((*lambdaEntryObject)(e))
+}
+
+// UnmarshalJSON accepts the name of a Lambda or an object with per-Lambda settings. Unknown fields are rejected.
+func (e *LambdaEntry) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &e.Name)
+	}
+
+	dc := json.NewDecoder(bytes.NewReader(data))
+	dc.DisallowUnknownFields()
+
+	return dc.Decode((*lambdaEntryObject)(e))
+}
+
+// FunctionName returns the name of the Lambda. The name is extracted from the ARN if the entry is identified by its ARN.
+func (e LambdaEntry) FunctionName() string {
+	parsed, err := arn.Parse(e.Name)
+	if err != nil {
+		return e.Name
+	}
+
+	return strings.TrimPrefix(parsed.Resource, "function:")
+}
+
+// FunctionRegion returns the region the entry is limited to. The region of the ARN is used if no region is provided.
+// An empty string is returned if the entry applies to all regions.
+func (e LambdaEntry) FunctionRegion() string {
+	if e.Region != "" {
+		return e.Region
+	}
+
+	parsed, err := arn.Parse(e.Name)
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Region
+}
+
+// HasOverrides returns true if the entry contains settings that take precedence over the CLI flags.
+func (e LambdaEntry) HasOverrides() bool {
+	return e.Retain != nil || e.SkipAliases != nil || e.OlderThan != ""
+}
+
+// Validate ensures the entry identifies a single Lambda and that its settings are valid.
+func (e LambdaEntry) Validate() error {
+	if e.Name == "" {
+		return errors.New("a Lambda entry is missing its name")
+	}
+
+	if arn.IsARN(e.Name) {
+		parsed, err := arn.Parse(e.Name)
+		if err != nil || parsed.Service != "lambda" || !strings.HasPrefix(parsed.Resource, "function:") || strings.Contains(strings.TrimPrefix(parsed.Resource, "function:"), ":") {
+			return fmt.Errorf("%s is not an unqualified Lambda function ARN", e.Name)
+		}
+
+		if e.Region != "" && e.Region != parsed.Region {
+			return fmt.Errorf("the region %s of %s does not match the region of its ARN", e.Region, e.Name)
+		}
+	}
+
+	if e.Retain != nil && *e.Retain < 0 {
+		return fmt.Errorf("the retain value of %s must be 0 or greater", e.Name)
+	}
+
+	if e.OlderThan != "" {
+		if _, err := ParseOlderThan(e.OlderThan); err != nil {
+			return fmt.Errorf("the olderThan value of %s is invalid: %w", e.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ParseOlderThan converts an age to a duration. The value is a Go duration, such as 72h, or a number of days or weeks, such as 30d or 2w.
+func ParseOlderThan(value string) (time.Duration, error) {
+	var (
+		unit     time.Duration
+		duration time.Duration
+		err      error
+	)
+
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+
+	if unit != 0 {
+		count, convErr := strconv.Atoi(value[:len(value)-1])
+		if convErr != nil {
+			return 0, fmt.Errorf("%s is not a valid number of days or weeks", value)
+		}
+
+		duration = time.Duration(count) * unit
+	} else {
+		duration, err = time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if duration <= 0 {
+		return 0, fmt.Errorf("%s must be greater than 0", value)
+	}
+
+	return duration, nil
+}
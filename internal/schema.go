@@ -0,0 +1,144 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"math"
+	"regexp"
+)
+
+// LambdaListSchema is the JSON Schema of the custom list and exclude file formats. The file is generated by GenerateLambdaListSchema.
+//
+//go:embed lambda-list.schema.json
+var LambdaListSchema []byte
+
+// The rules shared by ValidateLambdaList and the JSON Schema of the list format.
+const (
+	// functionNameExpr matches the characters allowed in the name of a Lambda.
+	functionNameExpr string = `[a-zA-Z0-9_-]{1,64}`
+	// functionARNPrefixExpr matches the prefix of an unqualified Lambda function ARN.
+	functionARNPrefixExpr string = `arn:aws[a-z-]*:lambda:[a-z0-9-]+:[0-9]{12}:function:`
+	// regionExpr matches the format of an AWS region.
+	regionExpr string = `[a-z]{2}(-[a-z]+)+-[0-9]+`
+	// olderThanExpr matches an age accepted by ParseOlderThan: a number of days or weeks, or a Go duration, greater than 0.
+	olderThanExpr string = `[0-9]*[1-9][0-9]*[dw]|(` + durationExpr + `)*` + positiveDurationExpr + `(` + durationExpr + `)*`
+	// durationExpr matches a single component of a Go duration, such as 30m.
+	durationExpr string = `[0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h)`
+	// positiveDurationExpr matches a component of a Go duration that is greater than 0.
+	positiveDurationExpr string = `([0-9]*[1-9][0-9]*(\.[0-9]+)?|[0-9]+\.[0-9]*[1-9][0-9]*)(ns|us|µs|ms|s|m|h)`
+	// retainMinimum and retainMaximum are the bounds of the retain value of an entry.
+	retainMinimum int = 0
+	retainMaximum int = math.MaxInt8
+)
+
+var (
+	// functionNamePattern matches the characters allowed in the name of a Lambda.
+	functionNamePattern = regexp.MustCompile(`^` + functionNameExpr + `$`)
+	// regionPattern matches the format of an AWS region.
+	regionPattern = regexp.MustCompile(`^` + regionExpr + `$`)
+	// olderThanPattern matches an age accepted by ParseOlderThan.
+	olderThanPattern = regexp.MustCompile(`^(` + olderThanExpr + `)$`)
+)
+
+// schemaNode is a node of a JSON Schema. Only the keywords used by the list format are supported.
+type schemaNode struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Properties           map[string]*schemaNode `json:"properties,omitempty"`
+	Items                *schemaNode            `json:"items,omitempty"`
+	OneOf                []*schemaNode          `json:"oneOf,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Minimum              *int                   `json:"minimum,omitempty"`
+	Maximum              *int                   `json:"maximum,omitempty"`
+	Defs                 map[string]*schemaNode `json:"$defs,omitempty"`
+}
+
+// GenerateLambdaListSchema returns the JSON Schema of the list format. The schema is built from the same rules as ValidateLambdaList so that they cannot drift apart.
+func GenerateLambdaListSchema() ([]byte, error) {
+	closed := false
+	minimum, maximum := retainMinimum, retainMaximum
+
+	schema := schemaNode{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       "glc Lambda list",
+		Description: "A custom list or an exclude file of the glc CLI. A JSON list can also be a top-level array of entries.",
+		OneOf: []*schemaNode{
+			{
+				Type:                 "object",
+				AdditionalProperties: &closed,
+				Required:             []string{"lambdas"},
+				Properties: map[string]*schemaNode{
+					"lambdas": {Ref: "#/$defs/lambdas"},
+				},
+			},
+			{Ref: "#/$defs/lambdas"},
+		},
+		Defs: map[string]*schemaNode{
+			"lambdas": {
+				Description: "The Lambdas of the list. An entry is the name or the ARN of a Lambda, or an object with per-Lambda settings.",
+				Type:        "array",
+				Items: &schemaNode{
+					OneOf: []*schemaNode{
+						{Ref: "#/$defs/function"},
+						{Ref: "#/$defs/entry"},
+					},
+				},
+			},
+			"function": {
+				Description: "The name or the unqualified ARN of a Lambda.",
+				Type:        "string",
+				Pattern:     `^(` + functionARNPrefixExpr + `)?` + functionNameExpr + `$`,
+			},
+			"entry": {
+				Type:                 "object",
+				AdditionalProperties: &closed,
+				Required:             []string{"name"},
+				Properties: map[string]*schemaNode{
+					"name": {Ref: "#/$defs/function"},
+					"retain": {
+						Description: "The number of versions to retain excluding $LATEST.",
+						Type:        "integer",
+						Minimum:     &minimum,
+						Maximum:     &maximum,
+					},
+					"olderThan": {
+						Description: "Only delete the versions last modified before the age, such as 72h, 30d, or 2w.",
+						Type:        "string",
+						Pattern:     olderThanPattern.String(),
+					},
+					"skipAliases": {
+						Description: "Skip the versions that have an alias attached.",
+						Type:        "boolean",
+					},
+					"region": {
+						Description: "Only clean the Lambda when the clean-up runs in this region.",
+						Type:        "string",
+						Pattern:     regionPattern.String(),
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "    ")
+
+	err := encoder.Encode(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"regexp"
+	"testing"
+)
+
+var updateSchema = flag.Bool("update", false, "regenerate lambda-list.schema.json")
+
+func TestLambdaListSchema(t *testing.T) {
+
+	schema, err := GenerateLambdaListSchema()
+	if err != nil {
+		t.Fatalf("Expected the schema to be generated but received %v", err)
+	}
+
+	if *updateSchema {
+		err = os.WriteFile("lambda-list.schema.json", schema, 0o644)
+		if err != nil {
+			t.Fatalf("Expected the schema to be written but received %v", err)
+		}
+
+		return
+	}
+
+	if !bytes.Equal(schema, LambdaListSchema) {
+		t.Errorf("Expected lambda-list.schema.json to match the validation rules. Run go test ./internal -run TestLambdaListSchema -update to regenerate it")
+	}
+}
+
+func TestLambdaListSchemaRules(t *testing.T) {
+
+	var schema struct {
+		Defs struct {
+			Entry struct {
+				Properties struct {
+					OlderThan struct {
+						Pattern string `json:"pattern"`
+					} `json:"olderThan"`
+					Region struct {
+						Pattern string `json:"pattern"`
+					} `json:"region"`
+				} `json:"properties"`
+			} `json:"entry"`
+		} `json:"$defs"`
+	}
+
+	err := json.Unmarshal(LambdaListSchema, &schema)
+	if err != nil {
+		t.Fatalf("Expected the schema to be valid JSON but received %v", err)
+	}
+
+	olderThan := regexp.MustCompile(schema.Defs.Entry.Properties.OlderThan.Pattern)
+
+	for _, value := range []string{"0d", "00w", "0h", "0h0m", "0.0h", "1h0m", "0.5h", "1.5h", "72h", "30d", "2w", "10", "d", "-1h", "1y"} {
+		_, parseErr := ParseOlderThan(value)
+		if olderThan.MatchString(value) != (parseErr == nil) {
+			t.Errorf("Expected the schema and ParseOlderThan to agree on %q but the schema match is %t and ParseOlderThan returned %v", value, olderThan.MatchString(value), parseErr)
+		}
+	}
+
+	region := regexp.MustCompile(schema.Defs.Entry.Properties.Region.Pattern)
+
+	for _, value := range []string{"us-east-1", "eu-central-2", "us-gov-west-1", "useast1", "US-EAST-1"} {
+		if region.MatchString(value) != regionPattern.MatchString(value) {
+			t.Errorf("Expected the schema and the validator to agree on the region %q", value)
+		}
+	}
+}
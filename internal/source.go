@@ -0,0 +1,294 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+const (
+	// StdinSource is the list source that reads the list from the standard input.
+	StdinSource string = "-"
+	// maxListSize is the maximum size of a list read from the standard input, an https:// URL, or an s3:// URL.
+	maxListSize int64 = 10 << 20
+)
+
+// S3GetObjectAPI is the subset of the Amazon S3 API used to read a list from an s3:// URL. A *s3.Client satisfies the interface.
+type S3GetObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// ListSourceOptions are the settings used to read a Lambda list.
+type ListSourceOptions struct {
+	// Column selects the column of a CSV list by its header name or by its 1-based index. The first column is used if empty.
+	Column string
+	// Stdin is read when the source is "-".
+	Stdin io.Reader
+	// HTTPClient downloads the lists of https:// sources. The default HTTP client is used if nil.
+	HTTPClient *http.Client
+	// S3 downloads the lists of s3:// sources.
+	S3 S3GetObjectAPI
+}
+
+/*
+ReadLambdaList returns the validated entries of a Lambda list read from a local file, the standard input ("-"), an https:// URL, or an s3://bucket/key URL.
+The type of the list is determined by the suffix of the file name or the URL path: json, yaml, yml, txt, or csv.
+The lists read from the standard input or from a URL without a known suffix are detected from their content. A list is read as CSV if a column is selected, otherwise as plain text with one name per line.
+*/
+func ReadLambdaList(ctx context.Context, source string, opts ListSourceOptions) ([]LambdaEntry, error) {
+	var (
+		content []byte
+		name    string
+		err     error
+	)
+
+	switch {
+	case source == StdinSource:
+		if opts.Stdin == nil {
+			return []LambdaEntry{}, errors.New("the standard input is not available")
+		}
+
+		content, err = readLimited(opts.Stdin, "the standard input")
+
+	case strings.HasPrefix(source, "https://"):
+		name = source
+		content, err = downloadHTTPS(ctx, source, opts.HTTPClient)
+
+	case strings.HasPrefix(source, "s3://"):
+		name = source
+		content, err = downloadS3(ctx, source, opts.S3)
+
+	default:
+		var fileType string
+
+		fileType, err = determineFileType(source)
+		if err != nil {
+			return []LambdaEntry{}, err
+		}
+
+		content, err = os.ReadFile(source)
+		if err != nil {
+			return []LambdaEntry{}, errors.New("unable to read the input file")
+		}
+
+		return ParseLambdaList(content, fileType, opts.Column)
+	}
+
+	if err != nil {
+		return []LambdaEntry{}, err
+	}
+
+	return ParseLambdaList(content, detectListType(name, content, opts.Column), opts.Column)
+}
+
+// detectListType returns the type of a list from the suffix of its name, or from its content if the suffix is not supported.
+func detectListType(name string, content []byte, column string) string {
+	if u, err := neturl.Parse(name); err == nil && name != "" {
+		if fileType := fileTypeOf(u.Path); fileType != "none" {
+			return fileType
+		}
+	}
+
+	trimmed := bytes.TrimSpace(content)
+
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")):
+		return "json"
+
+	case bytes.HasPrefix(trimmed, []byte("lambdas:")) || bytes.Contains(trimmed, []byte("\nlambdas:")):
+		return "yaml"
+
+	case column != "":
+		return "csv"
+
+	default:
+		return "txt"
+	}
+}
+
+// parsePlainList returns the names of a txt or csv list along with their position.
+func parsePlainList(content []byte, fileType, column string) ([]ListedLambda, error) {
+	if fileType == "csv" {
+		return parseCSVList(content, column)
+	}
+
+	return parseTextList(content)
+}
+
+// parseTextList returns the names of a plain text list, one per line. Blank lines and lines starting with # are ignored.
+func parseTextList(content []byte) ([]ListedLambda, error) {
+	var output []ListedLambda
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+
+		output = append(output, ListedLambda{
+			LambdaEntry: LambdaEntry{Name: name},
+			Line:        line,
+			Column:      strings.Index(scanner.Text(), name) + 1,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read the text list. %s", err.Error())
+	}
+
+	return output, nil
+}
+
+/*
+parseCSVList returns the names of the selected column of a CSV list. Empty values and lines starting with # are ignored.
+If the column is selected by its header name, the first record is the header. If the column is selected by its 1-based index, or not selected, every record contains a name.
+*/
+func parseCSVList(content []byte, column string) ([]ListedLambda, error) {
+	var output []ListedLambda
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	index := 0
+	header := false
+
+	if column != "" {
+		n, err := strconv.Atoi(column)
+		if err == nil {
+			if n < 1 {
+				return nil, fmt.Errorf("invalid CSV column %s. A column index starts at 1", column)
+			}
+
+			index = n - 1
+		} else {
+			header = true
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the CSV list: %w", err)
+		}
+
+		if header {
+			header = false
+			index = -1
+
+			for i, value := range record {
+				if strings.EqualFold(strings.TrimSpace(value), column) {
+					index = i
+
+					break
+				}
+			}
+
+			if index < 0 {
+				return nil, fmt.Errorf("the CSV column %s was not found in the header %s", column, strings.Join(record, ","))
+			}
+
+			continue
+		}
+
+		if index >= len(record) || strings.TrimSpace(record[index]) == "" {
+			continue
+		}
+
+		line, col := reader.FieldPos(index)
+
+		output = append(output, ListedLambda{
+			LambdaEntry: LambdaEntry{Name: strings.TrimSpace(record[index])},
+			Line:        line,
+			Column:      col,
+		})
+	}
+
+	return output, nil
+}
+
+// downloadHTTPS returns the content of an https:// URL. A response other than 200 OK is reported as an error.
+func downloadHTTPS(ctx context.Context, source string, client *http.Client) ([]byte, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid list URL %s. %s", source, err.Error())
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download the list %s. %s", source, err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download the list %s. The server responded with %s", source, resp.Status)
+	}
+
+	return readLimited(resp.Body, source)
+}
+
+// downloadS3 returns the content of an s3://bucket/key URL.
+func downloadS3(ctx context.Context, source string, svc S3GetObjectAPI) ([]byte, error) {
+	if svc == nil {
+		return nil, errors.New("an Amazon S3 client is required to read a list from " + source)
+	}
+
+	bucket, key, found := strings.Cut(strings.TrimPrefix(source, "s3://"), "/")
+	if !found || bucket == "" || key == "" {
+		return nil, fmt.Errorf("invalid list URL %s. Use the format s3://bucket/key", source)
+	}
+
+	out, err := svc.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to download the list %s. %s", source, err.Error())
+	}
+	defer out.Body.Close()
+
+	return readLimited(out.Body, source)
+}
+
+// readLimited reads a list of up to maxListSize bytes. A larger list is reported as an error.
+func readLimited(r io.Reader, source string) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxListSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the list from %s. %s", source, err.Error())
+	}
+
+	if int64(len(content)) > maxListSize {
+		return nil, fmt.Errorf("the list from %s exceeds the maximum size of %d bytes", source, maxListSize)
+	}
+
+	return content, nil
+}
@@ -0,0 +1,149 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// fakeS3 returns the content of a single object.
+type fakeS3 struct {
+	bucket, key, content string
+}
+
+func (f fakeS3) GetObject(_ context.Context, params *s3.GetObjectInput, _ ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if *params.Bucket != f.bucket || *params.Key != f.key {
+		return nil, errors.New("NoSuchKey")
+	}
+
+	return &s3.GetObjectOutput{Body: io.NopCloser(strings.NewReader(f.content))}, nil
+}
+
+// names returns the names of the entries.
+func names(entries []LambdaEntry) string {
+	var output []string
+	for _, entry := range entries {
+		output = append(output, entry.Name)
+	}
+
+	return strings.Join(output, ",")
+}
+
+func TestReadLambdaListFiles(t *testing.T) {
+
+	tests := map[string]ListSourceOptions{
+		"../tests/test.txt":  {},
+		"../tests/test.csv":  {Column: "functionname"},
+		"../tests/test.yaml": {},
+		"../tests/test.json": {},
+	}
+
+	for file, opts := range tests {
+		got, err := ReadLambdaList(context.Background(), file, opts)
+		if err != nil || names(got) != "stopEC2-instances,putControls" {
+			t.Errorf("Failed to read %s. Expected stopEC2-instances,putControls but received %s %v", file, names(got), err)
+		}
+	}
+
+	got, err := ReadLambdaList(context.Background(), "../tests/test.csv", ListSourceOptions{Column: "3"})
+	if err != nil || names(got) != "runtime,go1.x,python3.12,nodejs20.x" {
+		t.Errorf("Failed to read the third column. Expected the runtimes but received %s %v", names(got), err)
+	}
+
+	_, err = ReadLambdaList(context.Background(), "../tests/test.csv", ListSourceOptions{Column: "missing"})
+	if err == nil {
+		t.Errorf("Expected an error for a missing column but received %v", err)
+	}
+}
+
+func TestReadLambdaListStdin(t *testing.T) {
+
+	tests := map[string]ListSourceOptions{
+		"func1\n\nfunc2\n":                            {},
+		`{"lambdas": ["func1", "func2"]}`:             {},
+		"lambdas:\n  - func1\n  - name: func2\n":      {},
+		"name,runtime\nfunc1,go\nfunc2,python\n":      {Column: "name"},
+		"# generated by a report\nfunc1\n  func2  \n": {},
+	}
+
+	for content, opts := range tests {
+		opts.Stdin = strings.NewReader(content)
+
+		got, err := ReadLambdaList(context.Background(), StdinSource, opts)
+		if err != nil || names(got) != "func1,func2" {
+			t.Errorf("Failed to read %q. Expected func1,func2 but received %s %v", content, names(got), err)
+		}
+	}
+
+	_, err := ReadLambdaList(context.Background(), StdinSource, ListSourceOptions{})
+	if err == nil {
+		t.Errorf("Expected an error without a standard input but received %v", err)
+	}
+
+	// The output of aws lambda list-functions piped through jq is a JSON array of names.
+	for _, content := range []string{"[\n  \"func1\",\n  \"func2\"\n]\n", `["func1", {"name": "func2", "retain": 2}]`} {
+		got, err := ReadLambdaList(context.Background(), StdinSource, ListSourceOptions{Stdin: strings.NewReader(content)})
+		if err != nil || names(got) != "func1,func2" {
+			t.Errorf("Failed to read the JSON array %q. Expected func1,func2 but received %s %v", content, names(got), err)
+		}
+	}
+
+	_, err = ReadLambdaList(context.Background(), StdinSource, ListSourceOptions{Stdin: strings.NewReader(`[{"name": "func1", "retain": -1}]`)})
+	if err == nil {
+		t.Errorf("Expected an error for an invalid entry of a JSON array but received %v", err)
+	}
+}
+
+func TestReadLambdaListHTTPS(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lists/functions.yaml" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		_, _ = w.Write([]byte("lambdas:\n  - func1\n  - func2\n"))
+	}))
+	defer server.Close()
+
+	opts := ListSourceOptions{HTTPClient: server.Client()}
+
+	got, err := ReadLambdaList(context.Background(), server.URL+"/lists/functions.yaml", opts)
+	if err != nil || names(got) != "func1,func2" {
+		t.Errorf("Failed to download the list. Expected func1,func2 but received %s %v", names(got), err)
+	}
+
+	_, err = ReadLambdaList(context.Background(), server.URL+"/missing.yaml", opts)
+	if err == nil {
+		t.Errorf("Expected an error for a missing list but received %v", err)
+	}
+}
+
+func TestReadLambdaListS3(t *testing.T) {
+
+	opts := ListSourceOptions{
+		S3: fakeS3{bucket: "reports", key: "lambda/unused.csv", content: "func1,2024-01-01\nfunc2,2024-02-01\n"},
+	}
+
+	got, err := ReadLambdaList(context.Background(), "s3://reports/lambda/unused.csv", opts)
+	if err != nil || names(got) != "func1,func2" {
+		t.Errorf("Failed to download the list. Expected func1,func2 but received %s %v", names(got), err)
+	}
+
+	for _, source := range []string{"s3://reports/missing.csv", "s3://reports", "s3:///key.csv"} {
+		_, err = ReadLambdaList(context.Background(), source, opts)
+		if err == nil {
+			t.Errorf("Expected an error for %s but received %v", source, err)
+		}
+	}
+}
@@ -4,9 +4,45 @@
 package internal
 
 type CustomDeleteListJson struct {
-	Lambdas []string `json:"lambdas"`
+	Lambdas []LambdaEntry `json:"lambdas"`
 }
 
 type CustomDeleteListYaml struct {
-	Lambdas []string `yaml:"lambdas"`
+	Lambdas []LambdaEntry `yaml:"lambdas"`
+}
+
+// LambdaEntry is an entry of a custom Lambda list. An entry is either the name of a Lambda or an object with per-Lambda settings.
+type LambdaEntry struct {
+	// Name is the name or the full ARN of the Lambda.
+	Name string `json:"name" yaml:"name"`
+	// Retain is the number of versions to retain excluding $LATEST. It takes precedence over the count flag.
+	Retain *int8 `json:"retain,omitempty" yaml:"retain,omitempty"`
+	// OlderThan limits the deletion to the versions last modified before the duration, such as 72h, 30d, or 2w.
+	OlderThan string `json:"olderThan,omitempty" yaml:"olderThan,omitempty"`
+	// SkipAliases skips the versions that have an alias attached. It takes precedence over the skip-aliases flag.
+	SkipAliases *bool `json:"skipAliases,omitempty" yaml:"skipAliases,omitempty"`
+	// Region limits the entry to the clean-up of a single region. The region of an ARN is used if empty.
+	Region string `json:"region,omitempty" yaml:"region,omitempty"`
+}
+
+// CLIConfigFile is the content of the glc configuration file.
+type CLIConfigFile struct {
+	// Targets are the named clean-up presets.
+	Targets map[string]Target `yaml:"targets"`
+	// Settings are the default values of the CLI flags, keyed by the name of the flag.
+	Settings map[string]any `yaml:",inline"`
+}
+
+// Target is a named clean-up preset of the configuration file.
+type Target struct {
+	Profile         string   `yaml:"profile"`
+	Region          string   `yaml:"region"`
+	Regions         []string `yaml:"regions"`
+	RoleARN         string   `yaml:"role-arn"`
+	Count           *int8    `yaml:"count"`
+	SkipAliases     *bool    `yaml:"skip-aliases"`
+	Include         []string `yaml:"include"`
+	Exclude         []string `yaml:"exclude"`
+	OlderThan       string   `yaml:"older-than"`
+	KeepDescription []string `yaml:"keep-description"`
 }
@@ -0,0 +1,413 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	yaml "gopkg.in/yaml.v3"
+)
+
+var (
+	// yamlLinePattern extracts the line of a YAML syntax error.
+	yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+)
+
+// ValidationError is a problem found at a line and column of a Lambda list. A column of 0 means the column is unknown.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Message string
+}
+
+// Error returns the position and the description of the problem.
+func (e ValidationError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("%d: %s", e.Line, e.Message)
+	}
+
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+}
+
+// ListedLambda is a valid entry of a Lambda list along with its position in the file.
+type ListedLambda struct {
+	LambdaEntry
+	Line   int
+	Column int
+}
+
+/*
+ValidateLambdaList checks a custom list or an exclude file against the rules of LambdaListSchema and reports every problem found along with its position.
+Duplicate names, which a JSON Schema cannot express, are reported as well. The valid entries are returned so that they can be checked further.
+The names of txt and csv lists are validated the same way. The column selects the column of a CSV list as described in ParseLambdaList.
+An error is returned if the file cannot be read or is not of a supported type.
+*/
+func ValidateLambdaList(file, column string) ([]ListedLambda, []ValidationError, error) {
+	fileType, err := determineFileType(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, errors.New("unable to read the input file")
+	}
+
+	if fileType == "txt" || fileType == "csv" {
+		listed, err := parsePlainList(content, fileType, column)
+		if err != nil {
+			return nil, []ValidationError{plainListError(err)}, nil
+		}
+
+		entries, errs := validatePlainList(listed)
+
+		return entries, errs, nil
+	}
+
+	if fileType == "json" && !json.Valid(content) {
+		return nil, []ValidationError{jsonSyntaxError(content)}, nil
+	}
+
+	var root yaml.Node
+
+	err = yaml.Unmarshal(content, &root)
+	if err != nil {
+		return nil, []ValidationError{yamlSyntaxError(err)}, nil
+	}
+
+	if len(root.Content) == 0 {
+		return nil, []ValidationError{{Line: 1, Column: 1, Message: "the file is empty"}}, nil
+	}
+
+	var (
+		entries []ListedLambda
+		errs    []ValidationError
+	)
+
+	// A JSON list can be a top-level array of entries.
+	if doc := root.Content[0]; fileType == "json" && doc.Kind == yaml.SequenceNode {
+		entries, errs = validateEntries(doc)
+	} else {
+		entries, errs = validateDocument(doc)
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		if errs[i].Line != errs[j].Line {
+			return errs[i].Line < errs[j].Line
+		}
+
+		return errs[i].Column < errs[j].Column
+	})
+
+	return entries, errs, nil
+}
+
+// validateDocument validates the top-level object of a Lambda list.
+func validateDocument(doc *yaml.Node) ([]ListedLambda, []ValidationError) {
+	var (
+		lambdas *yaml.Node
+		errs    []ValidationError
+	)
+
+	if doc.Kind != yaml.MappingNode {
+		return nil, []ValidationError{nodeError(doc, "the file must contain an object with a lambdas key")}
+	}
+
+	for i := 0; i < len(doc.Content); i += 2 {
+		key, value := doc.Content[i], doc.Content[i+1]
+
+		if key.Value != "lambdas" {
+			errs = append(errs, nodeError(key, "unknown key "+strconv.Quote(key.Value)))
+
+			continue
+		}
+
+		lambdas = value
+	}
+
+	if lambdas == nil {
+		return nil, append(errs, nodeError(doc, "missing required key \"lambdas\""))
+	}
+
+	if lambdas.Kind != yaml.SequenceNode {
+		return nil, append(errs, nodeError(lambdas, "lambdas must be a list"))
+	}
+
+	entries, entryErrs := validateEntries(lambdas)
+
+	return entries, append(errs, entryErrs...)
+}
+
+// validateEntries returns the valid entries of a sequence of Lambdas along with the problems of the invalid entries and the duplicates.
+func validateEntries(lambdas *yaml.Node) ([]ListedLambda, []ValidationError) {
+	var (
+		entries []ListedLambda
+		errs    []ValidationError
+	)
+
+	for _, item := range lambdas.Content {
+		entry, itemErrs := validateEntry(item)
+		if len(itemErrs) > 0 {
+			errs = append(errs, itemErrs...)
+
+			continue
+		}
+
+		entries = append(entries, ListedLambda{
+			LambdaEntry: entry,
+			Line:        item.Line,
+			Column:      item.Column,
+		})
+	}
+
+	entries, duplicates := removeDuplicates(entries)
+
+	return entries, append(errs, duplicates...)
+}
+
+// validatePlainList validates the names of a txt or csv list.
+func validatePlainList(listed []ListedLambda) ([]ListedLambda, []ValidationError) {
+	var (
+		entries []ListedLambda
+		errs    []ValidationError
+	)
+
+	for _, item := range listed {
+		err := validateFunctionName(item.LambdaEntry)
+		if err == nil {
+			err = item.Validate()
+		}
+
+		if err != nil {
+			errs = append(errs, ValidationError{Line: item.Line, Column: item.Column, Message: err.Error()})
+
+			continue
+		}
+
+		entries = append(entries, item)
+	}
+
+	entries, duplicates := removeDuplicates(entries)
+
+	return entries, append(errs, duplicates...)
+}
+
+// removeDuplicates returns the entries without the Lambdas listed more than once for the same region. Each duplicate is reported.
+func removeDuplicates(listed []ListedLambda) ([]ListedLambda, []ValidationError) {
+	var (
+		entries []ListedLambda
+		errs    []ValidationError
+	)
+
+	seen := make(map[string]int)
+
+	for _, item := range listed {
+		key := item.FunctionName() + "@" + item.FunctionRegion()
+		if line, ok := seen[key]; ok {
+			errs = append(errs, ValidationError{
+				Line:    item.Line,
+				Column:  item.Column,
+				Message: fmt.Sprintf("duplicate Lambda %s, first listed on line %d", strconv.Quote(item.FunctionName()), line),
+			})
+
+			continue
+		}
+
+		seen[key] = item.Line
+
+		entries = append(entries, item)
+	}
+
+	return entries, errs
+}
+
+// validateEntry validates an entry of the lambdas list. An entry is a name or an object with per-Lambda settings.
+func validateEntry(item *yaml.Node) (LambdaEntry, []ValidationError) {
+	var (
+		entry    LambdaEntry
+		errs     []ValidationError
+		nameNode *yaml.Node
+	)
+
+	switch item.Kind {
+	case yaml.ScalarNode:
+		if item.ShortTag() == "!!null" {
+			return entry, []ValidationError{nodeError(item, "a Lambda entry cannot be empty")}
+		}
+
+		entry.Name = item.Value
+		nameNode = item
+
+	case yaml.MappingNode:
+		hasName := false
+
+		for i := 0; i < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+
+			switch key.Value {
+			case "name":
+				hasName = true
+
+				if value.Kind != yaml.ScalarNode || value.ShortTag() == "!!null" {
+					errs = append(errs, nodeError(value, "name must be a string"))
+
+					continue
+				}
+
+				entry.Name = value.Value
+				nameNode = value
+
+			case "retain":
+				retain, err := strconv.ParseInt(value.Value, 10, 8)
+				if value.ShortTag() != "!!int" || err != nil || retain < int64(retainMinimum) {
+					errs = append(errs, nodeError(value, "retain must be an integer between 0 and 127"))
+
+					continue
+				}
+
+				retainValue := int8(retain)
+				entry.Retain = &retainValue
+
+			case "olderThan":
+				if _, err := ParseOlderThan(value.Value); value.ShortTag() != "!!str" || !olderThanPattern.MatchString(value.Value) || err != nil {
+					errs = append(errs, nodeError(value, "olderThan must be a duration such as 72h, 30d, or 2w"))
+
+					continue
+				}
+
+				entry.OlderThan = value.Value
+
+			case "skipAliases":
+				if value.ShortTag() != "!!bool" {
+					errs = append(errs, nodeError(value, "skipAliases must be true or false"))
+
+					continue
+				}
+
+				skipAliases := value.Value == "true"
+				entry.SkipAliases = &skipAliases
+
+			case "region":
+				if value.ShortTag() != "!!str" || !regionPattern.MatchString(value.Value) {
+					errs = append(errs, nodeError(value, "invalid region "+strconv.Quote(value.Value)))
+
+					continue
+				}
+
+				entry.Region = value.Value
+
+			default:
+				errs = append(errs, nodeError(key, "unknown key "+strconv.Quote(key.Value)))
+			}
+		}
+
+		if !hasName {
+			errs = append(errs, nodeError(item, "missing required key \"name\""))
+		}
+
+	default:
+		return entry, []ValidationError{nodeError(item, "a Lambda entry must be a name or an object")}
+	}
+
+	if nameNode != nil {
+		if entry.Name == "" {
+			errs = append(errs, nodeError(nameNode, "a Lambda entry is missing its name"))
+		} else if err := validateFunctionName(entry); err != nil {
+			errs = append(errs, nodeError(nameNode, err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return entry, errs
+	}
+
+	if err := entry.Validate(); err != nil {
+		return entry, []ValidationError{nodeError(item, err.Error())}
+	}
+
+	return entry, nil
+}
+
+// validateFunctionName ensures the name of an entry, or the name in its ARN, only contains the characters allowed by AWS Lambda.
+func validateFunctionName(entry LambdaEntry) error {
+	if arn.IsARN(entry.Name) {
+		// The structure of the ARN is validated by LambdaEntry.Validate.
+		parsed, err := arn.Parse(entry.Name)
+		if err != nil || strings.Contains(strings.TrimPrefix(parsed.Resource, "function:"), ":") {
+			return nil
+		}
+	}
+
+	if !functionNamePattern.MatchString(entry.FunctionName()) {
+		return fmt.Errorf("invalid function name %s. A name contains 1 to 64 letters, numbers, hyphens, or underscores", strconv.Quote(entry.FunctionName()))
+	}
+
+	return nil
+}
+
+// nodeError returns a ValidationError at the position of the node.
+func nodeError(node *yaml.Node, message string) ValidationError {
+	return ValidationError{
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: message,
+	}
+}
+
+// plainListError returns the position and the description of an error of a txt or csv list.
+func plainListError(err error) ValidationError {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return ValidationError{Line: parseErr.Line, Column: parseErr.Column, Message: "invalid CSV: " + parseErr.Err.Error()}
+	}
+
+	return ValidationError{Line: 1, Message: err.Error()}
+}
+
+// jsonSyntaxError returns the position and the description of the syntax error of an invalid JSON document.
+func jsonSyntaxError(content []byte) ValidationError {
+	var value any
+
+	err := json.Unmarshal(content, &value)
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return ValidationError{Line: 1, Column: 1, Message: "invalid JSON"}
+	}
+
+	before := content[:syntaxErr.Offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := int(syntaxErr.Offset) - bytes.LastIndexByte(before, '\n') - 1
+
+	return ValidationError{
+		Line:    line,
+		Column:  column,
+		Message: "invalid JSON: " + syntaxErr.Error(),
+	}
+}
+
+// yamlSyntaxError returns the position and the description of a YAML syntax error. The YAML parser only reports the line of the error.
+func yamlSyntaxError(err error) ValidationError {
+	line := 1
+
+	match := yamlLinePattern.FindStringSubmatch(err.Error())
+	if match != nil {
+		line, _ = strconv.Atoi(match[1])
+	}
+
+	return ValidationError{
+		Line:    line,
+		Message: "invalid YAML: " + strings.TrimPrefix(err.Error(), "yaml: "),
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLambdaList(t *testing.T) {
+
+	for _, file := range []string{"../tests/test.yaml", "../tests/test.json", "../tests/test-v2.yaml", "../tests/test-v2.json", "../tests/test-array.json"} {
+		entries, problems, err := ValidateLambdaList(file, "")
+		if err != nil || len(problems) != 0 || len(entries) == 0 {
+			t.Errorf("Expected %s to be valid but received %v %v", file, problems, err)
+		}
+	}
+
+	entries, problems, err := ValidateLambdaList("../tests/test.csv", "FunctionName")
+	if err != nil || len(problems) != 0 || len(entries) != 2 || entries[1].Line != 5 || entries[1].Column != 14 {
+		t.Errorf("Expected the CSV list to be valid with the position of each name but received %v %v %v", entries, problems, err)
+	}
+
+	_, problems, _ = ValidateLambdaList("../tests/test.csv", "3")
+	if len(problems) != 3 || problems[0].Line != 4 || problems[0].Column != 32 {
+		t.Errorf("Expected the runtimes to be reported as invalid names but received %v", problems)
+	}
+
+	entries, _, _ = ValidateLambdaList("../tests/test-v2.yaml", "")
+	if entries[1].Line != 6 || entries[1].Column != 5 || *entries[1].Retain != 3 {
+		t.Errorf("Expected putControls on line 6, column 5 but received %+v", entries[1])
+	}
+}
+
+func TestValidateLambdaListProblems(t *testing.T) {
+
+	entries, problems, err := ValidateLambdaList("../tests/invalid-list.yaml", "")
+	if err != nil {
+		t.Fatalf("Expected no error to be returned but received %v", err)
+	}
+
+	want := []string{
+		"6:11: invalid function name",
+		"7:13: retain must be an integer",
+		"9:5: unknown key \"keep\"",
+		"10:5: duplicate Lambda \"stopEC2-instances\", first listed on line 5",
+		"12:13: invalid region",
+		"13:1: unknown key \"extra\"",
+	}
+
+	if len(problems) != len(want) {
+		t.Fatalf("Expected %d problems but received %v", len(want), problems)
+	}
+
+	for i := range want {
+		if !strings.HasPrefix(problems[i].Error(), want[i]) {
+			t.Errorf("Expected the problem to start with %s but received %s", want[i], problems[i].Error())
+		}
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("Expected only the first entry to be valid but received %v", entries)
+	}
+}
+
+func TestValidateLambdaListSyntax(t *testing.T) {
+
+	_, problems, err := ValidateLambdaList("../tests/invalid.json", "")
+	if err != nil || len(problems) != 1 || problems[0].Line != 2 || problems[0].Column == 0 {
+		t.Errorf("Expected a JSON syntax error on line 2 but received %v %v", problems, err)
+	}
+
+	_, problems, err = ValidateLambdaList("../tests/invalid.yaml", "")
+	if err != nil || len(problems) != 1 || problems[0].Line != 4 {
+		t.Errorf("Expected a YAML syntax error on line 4 but received %v %v", problems, err)
+	}
+
+	_, _, err = ValidateLambdaList("../tests/handler.zip", "")
+	if err == nil {
+		t.Errorf("Expected an error for an unsupported file type but received %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// validateAliasPatterns returns an error if one of the patterns of the aliases to prune is malformed.
+func validateAliasPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid prune-aliases pattern %s: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+/*
+pruneAliases deletes the aliases of a Lambda with a name that matches one of the PruneAliases patterns, so that the versions they point to can be removed by the clean-up.
+If AliasOlderThan is set, only the aliases that point to a version last modified more than the duration ago are deleted. The AWS Lambda API does not report the age of an alias.
+The versions of the aliases are looked up and the aliases are deleted one at a time within the rate limit of the worker pool. In a dry run, the aliases are reported but not deleted.
+The names of the aliases deleted, or to be deleted in a dry run, are returned. An error is returned if the aliases cannot be listed or deleted.
+*/
+func pruneAliases(ctx context.Context, svc LambdaAPI, pool *workerPool, item types.FunctionConfiguration, opts Options, now time.Time) ([]string, error) {
+	var (
+		aliases []types.AliasConfiguration
+		pruned  []string
+	)
+
+	// Lambdas resumed from a state file are only identified by their name.
+	functionID := item.FunctionName
+	if item.FunctionArn != nil {
+		functionID = item.FunctionArn
+	}
+
+	p := lambda.NewListAliasesPaginator(svc, &lambda.ListAliasesInput{
+		FunctionName: aws.String(*functionID),
+		MaxItems:     aws.Int32(maxItems),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return pruned, err
+		}
+
+		aliases = append(aliases, page.Aliases...)
+	}
+
+	for _, alias := range aliases {
+		name := aws.ToString(alias.Name)
+		version := aws.ToString(alias.FunctionVersion)
+
+		if !slices.ContainsFunc(opts.PruneAliases, func(pattern string) bool {
+			match, _ := path.Match(pattern, name)
+
+			return match
+		}) {
+			continue
+		}
+
+		if opts.AliasOlderThan > 0 {
+			err := pool.wait(ctx)
+			if err != nil {
+				return pruned, err
+			}
+
+			stale, err := isStaleAlias(ctx, svc, *item.FunctionName, version, now.Add(-opts.AliasOlderThan))
+			if err != nil {
+				return pruned, err
+			}
+
+			if !stale {
+				log.Debug(fmt.Sprintf("Retaining the alias %s of %s as version %s was modified less than %s ago", name, *item.FunctionName, version, opts.AliasOlderThan))
+
+				continue
+			}
+		}
+
+		if opts.DryRun {
+			log.Info(fmt.Sprintf("The alias %s of %s pointing to version %s will be deleted in an actual execution", name, *item.FunctionName, version))
+
+			pruned = append(pruned, name)
+
+			continue
+		}
+
+		err := pool.wait(ctx)
+		if err != nil {
+			return pruned, err
+		}
+
+		// An in-flight deletion is allowed to complete even if the context is cancelled.
+		_, err = svc.DeleteAlias(context.WithoutCancel(ctx), &lambda.DeleteAliasInput{
+			FunctionName: item.FunctionName,
+			Name:         alias.Name,
+		})
+		if err != nil {
+			return pruned, fmt.Errorf("failed to delete the alias %s of %s: %w", name, *item.FunctionName, err)
+		}
+
+		log.Info(fmt.Sprintf("Deleted the alias %s of %s pointing to version %s", name, *item.FunctionName, version))
+
+		pruned = append(pruned, name)
+	}
+
+	return pruned, nil
+}
+
+// isStaleAlias reports whether the version an alias points to was last modified before the cutoff. A version without a valid LastModified value is not stale.
+func isStaleAlias(ctx context.Context, svc LambdaAPI, name, version string, cutoff time.Time) (bool, error) {
+	result, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String(name),
+		Qualifier:    aws.String(version),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if result.Configuration == nil || result.Configuration.LastModified == nil {
+		return false, nil
+	}
+
+	lastModified, err := time.Parse(lastModifiedLayout, *result.Configuration.LastModified)
+	if err != nil {
+		return false, nil
+	}
+
+	return lastModified.Before(cutoff), nil
+}
+
+// excludeAliases returns the aliases with a name that is not in the list of names.
+func excludeAliases(aliases []types.AliasConfiguration, names []string) []types.AliasConfiguration {
+	if len(names) == 0 {
+		return aliases
+	}
+
+	var output []types.AliasConfiguration
+
+	for _, alias := range aliases {
+		if !slices.Contains(names, aws.ToString(alias.Name)) {
+			output = append(output, alias)
+		}
+	}
+
+	return output
+}
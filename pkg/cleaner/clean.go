@@ -0,0 +1,877 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/dustin/go-humanize"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Per AWS API Valid Range: Minimum value of 1. Maximum value of 10000.
+	maxItems int32 = 10000
+	// Per AWS API Valid Range: Minimum value of 1. Maximum value of 50.
+	maxInvokeConfigItems int32 = 50
+	// lastModifiedLayout is the format of the LastModified value of a Lambda version.
+	lastModifiedLayout string = "2006-01-02T15:04:05.000-0700"
+)
+
+// displayDuration calculates the duration based on a provided start time.
+func displayDuration(startTime time.Time) {
+	var (
+		elapsedTime float64
+		timeUnit    string
+	)
+
+	t1 := time.Now()
+
+	tempTime := t1.Sub(startTime)
+	if tempTime.Minutes() > 1 {
+		elapsedTime = tempTime.Minutes()
+		timeUnit = "m"
+	} else {
+		elapsedTime = tempTime.Seconds()
+		timeUnit = "s"
+	}
+
+	log.Infof("Job Duration Time: %f%s", elapsedTime, timeUnit)
+}
+
+// generateDeleteInputStructs takes a list of lambda.DeleteFunctionInput and a boolean value to determine if the user wants more details. The function returns a list of lambda.DeleteFunctionInput
+// An error is returned if the function fails to execute.
+func generateDeleteInputStructs(versionsList [][]types.FunctionConfiguration, details bool) ([][]lambda.DeleteFunctionInput, error) {
+	var (
+		returnError error
+		output      [][]lambda.DeleteFunctionInput
+	)
+
+	for _, version := range versionsList {
+		var tempList []lambda.DeleteFunctionInput
+
+		var functionName string
+
+		for _, entry := range version {
+			if *entry.Version != "$LATEST" {
+				if functionName == "" {
+					functionName = *entry.FunctionName
+				}
+
+				deleteItem := &lambda.DeleteFunctionInput{
+					FunctionName: entry.FunctionName,
+					Qualifier:    entry.Version,
+				}
+
+				tempList = append(tempList, *deleteItem)
+			}
+		}
+
+		if details && functionName != "" {
+			log.Info(fmt.Sprintf("%5d versions of %s to be removed", len(tempList), functionName))
+		}
+
+		output = append(output, tempList)
+	}
+
+	return output, returnError
+}
+
+// calculateSpaceRemoval returns the total size of all the versions to be deleted.
+// The function takes a list of lambda.DeleteFunctionInput and returns an int.
+func calculateSpaceRemoval(deleteList [][]types.FunctionConfiguration) int {
+	var (
+		size int
+	)
+
+	for _, lambda := range deleteList {
+		for _, version := range lambda {
+			if *version.Version != "$LATEST" {
+				size = size + int(version.CodeSize)
+			}
+		}
+	}
+
+	return size
+}
+
+// countDeleteVersions returns the total number of versions to be deleted.
+// The function takes a list of lambda.DeleteFunctionInput and returns an int.
+func countDeleteVersions(deleteList [][]lambda.DeleteFunctionInput) int {
+	var (
+		versionsCount int
+	)
+
+	for _, lambda := range deleteList {
+		versionsCount = versionsCount + len(lambda)
+	}
+
+	return versionsCount
+}
+
+// deleteLambdaVersion takes a list of lambda.DeleteFunctionInput and deletes all the versions in the list
+// The function takes a context, a Lambda API client, a worker pool, and a list of lambda.DeleteFunctionInput. A variadic operator is used to allow the user to pass in multiple lists of lambda.DeleteFunctionInput
+// The versions are deleted one at a time within the rate limit of the worker pool. Each deletion is recorded in the state file, if one is provided.
+// The versions that were successfully deleted are returned so the caller can report on them, along with the versions skipped because they are replicated by Lambda@Edge.
+// Use this function with caution as it will delete all the versions in the list.
+func deleteLambdaVersion(ctx context.Context, svc LambdaAPI, pool *workerPool, state *checkpoint, deleteList ...[]lambda.DeleteFunctionInput) ([]lambda.DeleteFunctionInput, []lambda.DeleteFunctionInput, error) {
+	var (
+		returnError  error
+		deleted      []lambda.DeleteFunctionInput
+		edgeReplicas []lambda.DeleteFunctionInput
+	)
+
+	for _, versions := range deleteList {
+		for _, version := range versions {
+			err := pool.wait(ctx)
+			if err != nil {
+				return deleted, edgeReplicas, err
+			}
+
+			// An in-flight deletion is allowed to complete even if the context is cancelled.
+			_, err = svc.DeleteFunction(context.WithoutCancel(ctx), &version)
+			// A previous execution that stopped after the deletion but before recording it leaves the version pending in the state file.
+			var rnf *types.ResourceNotFoundException
+			if errors.As(err, &rnf) && state.pendingVersion(*version.FunctionName, *version.Qualifier) {
+				log.Debug("Version " + *version.Qualifier + " of " + *version.FunctionName + " was already deleted by a previous execution")
+
+				err = nil
+			}
+
+			if isEdgeReplicaError(err) {
+				log.Info("Skipping version " + *version.Qualifier + " of " + *version.FunctionName + ". protected: edge replica")
+
+				edgeReplicas = append(edgeReplicas, version)
+
+				continue
+			}
+
+			if err != nil {
+				returnError = errors.New("Failed to delete version " + *version.Qualifier + " of " + *version.FunctionName + ". \n Additional details: " + err.Error())
+
+				continue
+			}
+
+			deleted = append(deleted, version)
+
+			err = state.deleted(*version.FunctionName, *version.Qualifier)
+			if err != nil {
+				return deleted, edgeReplicas, err
+			}
+		}
+	}
+
+	return deleted, edgeReplicas, returnError
+}
+
+// isEdgeReplicaError reports whether a deletion failed because the version is replicated by Lambda@Edge.
+// The replicas of a version associated with a CloudFront distribution are removed by CloudFront hours after the association is removed. Until then, the AWS Lambda API rejects the deletion.
+func isEdgeReplicaError(err error) bool {
+	var ipv *types.InvalidParameterValueException
+	if !errors.As(err, &ipv) {
+		return false
+	}
+
+	return strings.Contains(ipv.ErrorMessage(), "replicated function")
+}
+
+// calculateDeletedSpace returns the total size of the versions that were successfully deleted.
+// The function takes the list of versions planned for deletion and the list of lambda.DeleteFunctionInput returned by deleteLambdaVersion.
+func calculateDeletedSpace(deleteList [][]types.FunctionConfiguration, deleted []lambda.DeleteFunctionInput) int64 {
+	var size int64
+
+	removed := make(map[string]bool, len(deleted))
+	for _, item := range deleted {
+		removed[*item.FunctionName+":"+*item.Qualifier] = true
+	}
+
+	for _, lambda := range deleteList {
+		for _, version := range lambda {
+			if removed[*version.FunctionName+":"+*version.Version] {
+				size = size + version.CodeSize
+			}
+		}
+	}
+
+	return size
+}
+
+// verifyDeletedVersions rescans the provided lambda and confirms that none of the deleted versions are still present.
+// An error is returned if a deleted version is still present or if the rescan fails.
+func verifyDeletedVersions(ctx context.Context, svc LambdaAPI, item types.FunctionConfiguration, deleted []lambda.DeleteFunctionInput, opts Options) error {
+	removed := make(map[string]bool, len(deleted))
+	for _, version := range deleted {
+		removed[*version.Qualifier] = true
+	}
+
+	versions, _, err := getAllLambdaVersion(ctx, svc, item, opts, nil)
+	if err != nil {
+		return fmt.Errorf("unable to verify the versions of %s: %w", *item.FunctionName, err)
+	}
+
+	for _, version := range versions {
+		if removed[*version.Version] {
+			return errors.New("version " + *version.Version + " of " + *item.FunctionName + " is still present after the clean-up")
+		}
+	}
+
+	return nil
+}
+
+// versionPredicate reports whether a Lambda version matches a condition.
+type versionPredicate func(version types.FunctionConfiguration) bool
+
+// getLambdasToDeleteList takes a list of lambda.FunctionConfiguration and a int8 value to determine how many versions to retain. The function returns a list of lambda.FunctionConfiguration.
+// The versions matching one of the keep predicates are retained in addition to the retained versions. A nil predicate is ignored.
+func getLambdasToDeleteList(list []types.FunctionConfiguration, retainCount int8, keep ...versionPredicate) []types.FunctionConfiguration {
+	var retainNumber int
+	// Ensure the passed in parameter is greater than zero
+	if retainCount >= 1 {
+		retainNumber = int(retainCount)
+	}
+
+	// If passed in parameter is less than zero than set the default value to 0
+	if retainCount < 1 {
+		retainNumber = 1
+	}
+
+	// This checks to ensure that we are not deleting a list that only contains $LATEST
+	if (len(list)) <= 1 || (int(retainNumber) >= len(list)) {
+		return nil
+	}
+
+	return excludeKept(list[retainNumber:], keep)
+}
+
+// excludeKept returns the versions that do not match any of the keep predicates.
+func excludeKept(list []types.FunctionConfiguration, keep []versionPredicate) []types.FunctionConfiguration {
+	var output []types.FunctionConfiguration
+
+	for _, item := range list {
+		kept := false
+
+		for _, predicate := range keep {
+			if predicate != nil && predicate(item) {
+				kept = true
+
+				break
+			}
+		}
+
+		if kept {
+			log.Debug(fmt.Sprintf("Retaining version %s of %s as it is set to be kept", aws.ToString(item.Version), aws.ToString(item.FunctionName)))
+
+			continue
+		}
+
+		output = append(output, item)
+	}
+
+	return output
+}
+
+// newDescriptionMatcher returns a predicate that matches the versions with a description that matches one of the patterns. A nil predicate is returned if no pattern is provided.
+// A pattern wrapped in slashes is a regular expression. Any other pattern uses the syntax of path.Match. An error is returned if a pattern is malformed.
+func newDescriptionMatcher(patterns []string) (versionPredicate, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	var matchers []func(string) bool
+
+	for _, pattern := range patterns {
+		if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+			expression, err := regexp.Compile(pattern[1 : len(pattern)-1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid keep description pattern %s: %w", pattern, err)
+			}
+
+			matchers = append(matchers, expression.MatchString)
+
+			continue
+		}
+
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid keep description pattern %s: %w", pattern, err)
+		}
+
+		matchers = append(matchers, func(description string) bool {
+			match, _ := path.Match(pattern, description)
+
+			return match
+		})
+	}
+
+	return func(version types.FunctionConfiguration) bool {
+		description := aws.ToString(version.Description)
+
+		for _, match := range matchers {
+			if match(description) {
+				return true
+			}
+		}
+
+		return false
+	}, nil
+}
+
+// newProtectedVersionMatcher returns a predicate that matches the versions with one of the qualified ARNs, or one of the names qualified with a version such as my-function:3.
+// A nil predicate is returned if no version is provided. An error is returned if a value does not identify a Lambda version.
+func newProtectedVersionMatcher(versions []string) (versionPredicate, error) {
+	if len(versions) == 0 {
+		return nil, nil
+	}
+
+	protected := make(map[string]bool, len(versions))
+
+	for _, value := range versions {
+		qualified := value
+
+		if arn.IsARN(value) {
+			parsed, err := arn.Parse(value)
+			if err != nil || parsed.Service != "lambda" || !strings.HasPrefix(parsed.Resource, "function:") {
+				return nil, fmt.Errorf("invalid protected version %s. The ARN of a Lambda version is required", value)
+			}
+
+			qualified = strings.TrimPrefix(parsed.Resource, "function:")
+		}
+
+		name, version, found := strings.Cut(qualified, ":")
+		if !found || name == "" || version == "" || strings.Contains(version, ":") {
+			return nil, fmt.Errorf("invalid protected version %s. The ARN of a Lambda version is required", value)
+		}
+
+		protected[name+":"+version] = true
+	}
+
+	return func(version types.FunctionConfiguration) bool {
+		return protected[aws.ToString(version.FunctionName)+":"+aws.ToString(version.Version)]
+	}, nil
+}
+
+// excludeIntegrations returns the versions without a version-specific resource-based policy or event invoke configuration, along with the number of versions skipped.
+// A version-specific policy grants a service, such as Amazon S3 or Amazon SNS, permission to invoke the version. An event invoke configuration sends the results of the version to its destinations.
+// Each request waits for the rate limit of the worker pool. Each skipped version is reported with the reason it is protected.
+// An error is returned if the policies or the configurations cannot be retrieved.
+func excludeIntegrations(ctx context.Context, svc LambdaAPI, pool *workerPool, name string, list []types.FunctionConfiguration) ([]types.FunctionConfiguration, int, error) {
+	var (
+		output  []types.FunctionConfiguration
+		skipped int
+	)
+
+	if len(list) == 0 {
+		return list, 0, nil
+	}
+
+	invokeConfigs := make(map[string]bool)
+
+	p := lambda.NewListFunctionEventInvokeConfigsPaginator(svc, &lambda.ListFunctionEventInvokeConfigsInput{
+		FunctionName: aws.String(name),
+		MaxItems:     aws.Int32(maxInvokeConfigItems),
+	})
+	for p.HasMorePages() {
+		err := pool.wait(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, config := range page.FunctionEventInvokeConfigs {
+			// The ARN of a configuration is qualified with the version or the alias it applies to.
+			parts := strings.Split(aws.ToString(config.FunctionArn), ":")
+			if len(parts) == 8 {
+				invokeConfigs[parts[7]] = true
+			}
+		}
+	}
+
+	for _, item := range list {
+		version := aws.ToString(item.Version)
+		if version == "$LATEST" {
+			output = append(output, item)
+
+			continue
+		}
+
+		reason := ""
+
+		if invokeConfigs[version] {
+			reason = "event invoke config"
+		} else {
+			err := pool.wait(ctx)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			hasPolicy, err := hasVersionPolicy(ctx, svc, name, version)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if hasPolicy {
+				reason = "resource policy"
+			}
+		}
+
+		if reason != "" {
+			log.Info("Skipping version " + version + " of " + name + ". protected: " + reason)
+
+			skipped++
+
+			continue
+		}
+
+		output = append(output, item)
+	}
+
+	return output, skipped, nil
+}
+
+// hasVersionPolicy reports whether a resource-based policy is attached to a version of a Lambda. The policy of the unqualified function is not considered.
+func hasVersionPolicy(ctx context.Context, svc LambdaAPI, name, version string) (bool, error) {
+	result, err := svc.GetPolicy(ctx, &lambda.GetPolicyInput{
+		FunctionName: aws.String(name),
+		Qualifier:    aws.String(version),
+	})
+	if err != nil {
+		var rnf *types.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return aws.ToString(result.Policy) != "", nil
+}
+
+// excludeProtected returns the Lambdas that are not protected. Each protected Lambda found in the list is reported.
+func excludeProtected(lambdaList []types.FunctionConfiguration, protected []string) []types.FunctionConfiguration {
+	if len(protected) == 0 {
+		return lambdaList
+	}
+
+	var output []types.FunctionConfiguration
+
+	for _, item := range lambdaList {
+		if slices.Contains(protected, *item.FunctionName) {
+			log.Info("Skipping " + *item.FunctionName + " as it is protected")
+
+			continue
+		}
+
+		output = append(output, item)
+	}
+
+	return output
+}
+
+// filterOlderThan returns the versions last modified more than the provided duration before now. A duration of 0 returns the list unchanged.
+// Versions without a valid LastModified value are retained.
+func filterOlderThan(list []types.FunctionConfiguration, olderThan time.Duration, now time.Time) []types.FunctionConfiguration {
+	if olderThan <= 0 {
+		return list
+	}
+
+	var output []types.FunctionConfiguration
+
+	cutoff := now.Add(-olderThan)
+
+	for _, item := range list {
+		if item.LastModified == nil {
+			continue
+		}
+
+		lastModified, err := time.Parse(lastModifiedLayout, *item.LastModified)
+		if err != nil {
+			log.Debug(fmt.Sprintf("Retaining version %s of %s as its last modified date %s is invalid", aws.ToString(item.Version), aws.ToString(item.FunctionName), *item.LastModified))
+
+			continue
+		}
+
+		if lastModified.Before(cutoff) {
+			output = append(output, item)
+		}
+	}
+
+	return output
+}
+
+/*
+updateInProgress returns the reason a Lambda is skipped because it is being deployed, or an empty string if it can be cleaned.
+If SkipUpdating is set, a Lambda with a state other than Active or Inactive, or with a last update that did not succeed, is skipped. Deleting its versions could conflict with the deployment.
+If UpdateGracePeriod is set, a Lambda modified less than the grace period ago is skipped.
+The configuration of the Lambda is retrieved with GetFunction within the rate limit of the worker pool when the state fields are missing, as ListFunctions does not return them.
+*/
+func updateInProgress(ctx context.Context, svc LambdaAPI, pool *workerPool, item types.FunctionConfiguration, opts Options, now time.Time) (string, error) {
+	if (opts.SkipUpdating && item.State == "") || (opts.UpdateGracePeriod > 0 && item.LastModified == nil) {
+		err := pool.wait(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		result, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{
+			FunctionName: item.FunctionName,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if result.Configuration != nil {
+			item = *result.Configuration
+		}
+	}
+
+	if opts.SkipUpdating {
+		if item.State != "" && item.State != types.StateActive && item.State != types.StateInactive {
+			return "state " + string(item.State), nil
+		}
+
+		if item.LastUpdateStatus != "" && item.LastUpdateStatus != types.LastUpdateStatusSuccessful {
+			return "last update " + string(item.LastUpdateStatus), nil
+		}
+	}
+
+	if opts.UpdateGracePeriod > 0 && item.LastModified != nil {
+		lastModified, err := time.Parse(lastModifiedLayout, *item.LastModified)
+		if err == nil && lastModified.After(now.Add(-opts.UpdateGracePeriod)) {
+			return "modified less than " + opts.UpdateGracePeriod.String() + " ago", nil
+		}
+	}
+
+	return "", nil
+}
+
+// getAllLambdas returns a list of all available lambdas in the AWS environment. The function takes a context, a Lambda API client, and a list of custom lambdas function names to delete.
+func getAllLambdas(ctx context.Context, svc LambdaAPI, customList []string) ([]types.FunctionConfiguration, error) {
+	var (
+		lambdasListOutput []types.FunctionConfiguration
+		returnError       error
+		input             *lambda.ListFunctionsInput
+	)
+
+	if len(customList) == 0 {
+		input = &lambda.ListFunctionsInput{
+			MaxItems: aws.Int32(maxItems),
+		}
+
+		p := lambda.NewListFunctionsPaginator(svc, input)
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				log.Error(err)
+
+				return lambdasListOutput, err
+			}
+
+			lambdasListOutput = append(lambdasListOutput, page.Functions...)
+		}
+	}
+
+	if len(customList) > 0 {
+		for _, item := range customList {
+			input := &lambda.GetFunctionInput{
+				FunctionName: aws.String(item),
+			}
+
+			result, err := svc.GetFunction(ctx, input)
+			if err != nil {
+				var rnf *types.ResourceNotFoundException
+				if errors.As(err, &rnf) {
+					log.Warn(fmt.Sprintf("The lambda function %s does not exist. Ensure you specified the correct name and that function exists and try again. ", item))
+					log.Warn("Skipping " + item)
+
+					continue
+				}
+
+				returnError = err
+			}
+
+			if result != nil && result.Configuration != nil {
+				lambdasListOutput = append(lambdasListOutput, *result.Configuration)
+			}
+		}
+	}
+
+	return lambdasListOutput, returnError
+}
+
+// filterLambdas returns the Lambdas with a name that matches one of the include patterns, if any, and none of the exclude patterns.
+// The patterns use the syntax of path.Match. An error is returned if a pattern is malformed.
+func filterLambdas(lambdaList []types.FunctionConfiguration, include, exclude []string) ([]types.FunctionConfiguration, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return lambdaList, nil
+	}
+
+	var output []types.FunctionConfiguration
+
+	for _, item := range lambdaList {
+		included := len(include) == 0
+
+		for _, pattern := range include {
+			match, err := path.Match(pattern, *item.FunctionName)
+			if err != nil {
+				return nil, fmt.Errorf("invalid include pattern %s: %w", pattern, err)
+			}
+
+			if match {
+				included = true
+
+				break
+			}
+		}
+
+		for _, pattern := range exclude {
+			match, err := path.Match(pattern, *item.FunctionName)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+			}
+
+			if match {
+				included = false
+
+				break
+			}
+		}
+
+		if included {
+			output = append(output, item)
+		} else {
+			log.Debug("Skipping " + *item.FunctionName + " as it is filtered out")
+		}
+	}
+
+	log.Info(len(lambdaList)-len(output), " Lambdas filtered out")
+
+	return output, nil
+}
+
+// getAllLambdaVersion returns a list of all available versions for a given lambda. The function takes a context, a Lambda API client, and a lambda.FunctionConfiguration.
+// The aliases named in prunedAliases are ignored so that a dry run reports the versions they unpin.
+// The aliases are listed once and returned when the aliased versions are skipped or the rollback windows of the aliases are retained.
+func getAllLambdaVersion(
+	ctx context.Context,
+	svc LambdaAPI,
+	item types.FunctionConfiguration,
+	opts Options,
+	prunedAliases []string,
+) ([]types.FunctionConfiguration, []types.AliasConfiguration, error) {
+	var (
+		lambdasLisOutput []types.FunctionConfiguration
+		aliasesOut       []types.AliasConfiguration
+		returnError      error
+		input            *lambda.ListVersionsByFunctionInput
+	)
+
+	input = &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String(*item.FunctionName),
+		MaxItems:     aws.Int32(maxItems),
+	}
+
+	p := lambda.NewListVersionsByFunctionPaginator(svc, input)
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			log.Error(err)
+
+			return lambdasLisOutput, nil, err
+		}
+
+		lambdasLisOutput = append(lambdasLisOutput, page.Versions...)
+	}
+
+	if opts.SkipAliases || opts.RetainBehindAlias > 0 {
+		// fetch the list of aliases for this function
+		var err error
+
+		aliasesOut, err = listAliases(ctx, svc, item, prunedAliases)
+		if err != nil {
+			log.Error(err)
+
+			return lambdasLisOutput, nil, err
+		}
+
+		log.Debug(fmt.Sprintf("Lamba function %s has %d aliases \n", *item.FunctionName, len(aliasesOut)))
+	}
+
+	if opts.SkipAliases {
+
+		// produce a new slice that includes only versions for which there is no alias
+		var result []types.FunctionConfiguration
+
+		for _, funConf := range lambdasLisOutput {
+			isAlias := false
+
+			for _, alias := range aliasesOut {
+				if alias.FunctionVersion != nil && *alias.FunctionVersion == *funConf.Version {
+					isAlias = true
+
+					break
+				}
+			}
+
+			if !isAlias {
+				result = append(result, funConf)
+			}
+		}
+
+		// return the pared down list of versions
+		lambdasLisOutput = result
+	}
+
+	// Sort list so that the former versions are listed first and $LATEST is listed last
+	sort.Sort(byVersion(lambdasLisOutput))
+
+	return lambdasLisOutput, aliasesOut, returnError
+}
+
+// listAliases returns the aliases of a Lambda. The aliases named in prunedAliases are ignored.
+func listAliases(ctx context.Context, svc LambdaAPI, item types.FunctionConfiguration, prunedAliases []string) ([]types.AliasConfiguration, error) {
+	var aliases []types.AliasConfiguration
+
+	// Lambdas resumed from a state file are only identified by their name.
+	functionID := item.FunctionName
+	if item.FunctionArn != nil {
+		functionID = item.FunctionArn
+	}
+
+	p := lambda.NewListAliasesPaginator(svc, &lambda.ListAliasesInput{
+		FunctionName: aws.String(*functionID),
+		MaxItems:     aws.Int32(maxItems),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		aliases = append(aliases, page.Aliases...)
+	}
+
+	return excludeAliases(aliases, prunedAliases), nil
+}
+
+/*
+excludeAliasRollback returns the versions that are not the target or in the rollback window of an alias.
+The rollback window of an alias is made of the count published versions immediately older than the version the alias points to, so that the alias can be rolled back regardless of the number of versions retained.
+The version an alias points to is always retained, as the AWS Lambda API rejects the deletion of a version with an alias.
+*/
+func excludeAliasRollback(versions []types.FunctionConfiguration, aliases []types.AliasConfiguration, count int8) []types.FunctionConfiguration {
+	var published []int64
+
+	for _, item := range versions {
+		number, err := strconv.ParseInt(aws.ToString(item.Version), 10, 64)
+		if err == nil {
+			published = append(published, number)
+		}
+	}
+
+	// Sort the published versions from the most recent to the oldest.
+	sort.Slice(published, func(i, j int) bool { return published[i] > published[j] })
+
+	// The reason each version is retained, keyed by version.
+	window := make(map[string]string)
+
+	for _, alias := range aliases {
+		target, err := strconv.ParseInt(aws.ToString(alias.FunctionVersion), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if _, ok := window[aws.ToString(alias.FunctionVersion)]; !ok {
+			window[aws.ToString(alias.FunctionVersion)] = "alias " + aws.ToString(alias.Name)
+		}
+
+		retained := 0
+
+		for _, number := range published {
+			if number >= target {
+				continue
+			}
+
+			if retained >= int(count) {
+				break
+			}
+
+			version := strconv.FormatInt(number, 10)
+			if _, ok := window[version]; !ok {
+				window[version] = "rollback window of the alias " + aws.ToString(alias.Name)
+			}
+
+			retained++
+		}
+	}
+
+	if len(window) == 0 {
+		return versions
+	}
+
+	var output []types.FunctionConfiguration
+
+	for _, item := range versions {
+		if reason, ok := window[aws.ToString(item.Version)]; ok {
+			log.Info("Skipping version " + aws.ToString(item.Version) + " of " + aws.ToString(item.FunctionName) + ". protected: " + reason)
+
+			continue
+		}
+
+		output = append(output, item)
+	}
+
+	return output
+}
+
+type byVersion []types.FunctionConfiguration
+
+func (a byVersion) Len() int { return len(a) }
+
+func (a byVersion) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+
+func (a byVersion) Less(i, j int) bool {
+	one, _ := strconv.ParseInt(*a[i].Version, 10, 32)
+	two, _ := strconv.ParseInt(*a[j].Version, 10, 32)
+
+	return one > two
+}
+
+// getLambdaStorage calculates the aggregate sum of all the functions' size.
+func getLambdaStorage(list []types.FunctionConfiguration) (int64, error) {
+	var (
+		sizeCounter int64
+		returnError error
+	)
+
+	for _, item := range list {
+		sizeCounter = sizeCounter + item.CodeSize
+	}
+
+	return sizeCounter, returnError
+}
+
+// CalculateFileSize returns the size of a file in bytes. The function takes an Options parameter to determine the number format type to return.
+func CalculateFileSize(value uint64, opts Options) string {
+	if opts.SizeIEC {
+		return humanize.IBytes(value)
+	}
+
+	return humanize.Bytes(value)
+}
@@ -0,0 +1,1289 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/docker/go-connections/nat"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner/cleanertest"
+	log "github.com/sirupsen/logrus"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/localstack"
+	"github.com/testcontainers/testcontainers-go/network"
+)
+
+// localstackContainerName is the fixed name for the reusable LocalStack container.
+const localstackContainerName = "go-lambda-cleanup-localstack"
+
+func TestGetLambdaStorage(t *testing.T) {
+
+	var (
+		lambdaList []types.FunctionConfiguration
+		want       int64
+	)
+
+	lambdaList = []types.FunctionConfiguration{
+		{
+			CodeSha256:       new(string),
+			CodeSize:         1200,
+			DeadLetterConfig: &types.DeadLetterConfig{},
+			Description:      aws.String("Test A"),
+		},
+		{
+			CodeSha256: new(string),
+			CodeSize:   1500,
+		},
+	}
+
+	want = 2700
+	got, err := getLambdaStorage(lambdaList)
+	if got != want || err != nil {
+		t.Fatalf("Lambda storage calculation invalid. Expected %d but received %d", want, got)
+	}
+}
+
+func TestFilterLambdas(t *testing.T) {
+
+	lambdaList := []types.FunctionConfiguration{
+		{FunctionName: aws.String("api-orders")},
+		{FunctionName: aws.String("api-legacy-users")},
+		{FunctionName: aws.String("worker-billing")},
+	}
+
+	got, err := filterLambdas(lambdaList, nil, nil)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("expected all the Lambdas to be returned without filters but received %d %v", len(got), err)
+	}
+
+	got, err = filterLambdas(lambdaList, []string{"api-*"}, []string{"api-legacy-*"})
+	if err != nil || len(got) != 1 || *got[0].FunctionName != "api-orders" {
+		t.Fatalf("expected only api-orders to be returned but received %v %v", got, err)
+	}
+
+	got, err = filterLambdas(lambdaList, nil, []string{"worker-*"})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("expected 2 Lambdas to be returned but received %d %v", len(got), err)
+	}
+
+	_, err = filterLambdas(lambdaList, []string{"api-["}, nil)
+	if err == nil {
+		t.Fatalf("expected an error to be returned for a malformed pattern but received %v", err)
+	}
+
+}
+
+func TestFilterOlderThan(t *testing.T) {
+
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	lambdaList := []types.FunctionConfiguration{
+		{Version: aws.String("3"), LastModified: aws.String("2024-05-31T12:00:00.000+0000")},
+		{Version: aws.String("2"), LastModified: aws.String("2024-05-01T12:00:00.000+0000")},
+		{Version: aws.String("1"), LastModified: aws.String("invalid")},
+	}
+
+	got := filterOlderThan(lambdaList, 0, now)
+	if len(got) != 3 {
+		t.Fatalf("expected the list to be unchanged without a duration but received %d versions", len(got))
+	}
+
+	got = filterOlderThan(lambdaList, 7*24*time.Hour, now)
+	if len(got) != 1 || *got[0].Version != "2" {
+		t.Fatalf("expected only version 2 to be returned but received %v", got)
+	}
+
+}
+
+func TestGetLambdasToDeleteList(t *testing.T) {
+	var (
+		retainNumber int8 = 2
+		lambdaList   []types.FunctionConfiguration
+		want         int = 3
+	)
+
+	lambdaList = []types.FunctionConfiguration{
+		{
+
+			CodeSha256:  new(string),
+			Version:     aws.String("1"),
+			CodeSize:    1200,
+			Description: aws.String("Test A"),
+		},
+		{
+
+			CodeSha256: new(string),
+			Version:    aws.String("2"),
+			CodeSize:   1500,
+		},
+		{
+
+			CodeSha256: new(string),
+			Version:    aws.String("3"),
+			CodeSize:   1500,
+		},
+		{
+
+			CodeSha256: new(string),
+			Version:    aws.String("4"),
+			CodeSize:   1500,
+		},
+		{
+
+			CodeSha256: new(string),
+			Version:    aws.String("5"),
+			CodeSize:   1500,
+		},
+	}
+
+	sort.Sort(byVersion(lambdaList))
+
+	got := getLambdasToDeleteList(lambdaList, retainNumber)
+
+	if len(got) != want {
+		t.Fatalf("Expected %d lambda configuration items to be returned but instead received %d", want, len(got))
+	}
+
+}
+
+func TestGetLambdasToDeleteListKeep(t *testing.T) {
+
+	lambdaList := []types.FunctionConfiguration{
+		{Version: aws.String("5"), Description: aws.String("hotfix-42")},
+		{Version: aws.String("4"), Description: aws.String("release-2024.05.1")},
+		{Version: aws.String("3")},
+		{Version: aws.String("2"), Description: aws.String("Release 2024.04")},
+		{Version: aws.String("1"), Description: aws.String("release-2024.03.2")},
+	}
+
+	keep, err := newDescriptionMatcher([]string{"release-*", "/^Release [0-9.]+$/"})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	got := getLambdasToDeleteList(lambdaList, 1, keep)
+	if len(got) != 1 || *got[0].Version != "3" {
+		t.Fatalf("expected only version 3 to be returned but received %v", got)
+	}
+
+	got = getLambdasToDeleteList(lambdaList, 1, nil)
+	if len(got) != 4 {
+		t.Fatalf("expected a nil predicate to be ignored but received %d versions", len(got))
+	}
+
+}
+
+func TestNewDescriptionMatcher(t *testing.T) {
+
+	keep, err := newDescriptionMatcher(nil)
+	if err != nil || keep != nil {
+		t.Fatalf("expected a nil predicate to be returned without patterns but received %v", err)
+	}
+
+	for _, pattern := range []string{"release-[", "/release-(/"} {
+		_, err = newDescriptionMatcher([]string{pattern})
+		if err == nil {
+			t.Errorf("expected an error to be returned for the malformed pattern %s but received %v", pattern, err)
+		}
+	}
+
+	keep, err = newDescriptionMatcher([]string{"/"})
+	if err != nil {
+		t.Fatalf("expected a single slash to be used as a glob pattern but received %v", err)
+	}
+
+	if !keep(types.FunctionConfiguration{Description: aws.String("/")}) {
+		t.Errorf("expected the description / to match the glob pattern /")
+	}
+
+}
+
+func TestGenerateDeleteInputStructs(t *testing.T) {
+
+	lambdaList := [][]types.FunctionConfiguration{
+		{
+			types.FunctionConfiguration{
+				CodeSha256:       new(string),
+				FunctionName:     aws.String("A"),
+				Version:          aws.String("1"),
+				CodeSize:         1200,
+				DeadLetterConfig: &types.DeadLetterConfig{},
+				Description:      aws.String("Test A"),
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("B"),
+				Version:      aws.String("2"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("C"),
+				Version:      aws.String("3"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("D"),
+				Version:      aws.String("4"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("E"),
+				Version:      aws.String("5"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("F"),
+				Version:      aws.String("$LATEST"),
+				CodeSize:     1500,
+			},
+		},
+		{
+			types.FunctionConfiguration{
+				CodeSha256:       new(string),
+				Version:          aws.String("1"),
+				FunctionName:     aws.String("A1"),
+				CodeSize:         1200,
+				DeadLetterConfig: &types.DeadLetterConfig{},
+				Description:      aws.String("Test A"),
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("A2"),
+				Version:      aws.String("2"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("A3"),
+				Version:      aws.String("3"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("A4"),
+				Version:      aws.String("$LATEST"),
+				CodeSize:     1500,
+			},
+		},
+	}
+
+	got, err := generateDeleteInputStructs(lambdaList, false)
+	if len(got) != 2 || err != nil {
+		t.Fatalf("Expected a lambda delete struct list to be 2 but go a length of %d", len(got))
+	}
+
+	if (*got[1][1].FunctionName != "A2") || err != nil {
+		t.Fatalf("Expected a lambda delete struct to have item A2 but instead got %v", *got[1][1].FunctionName)
+	}
+
+}
+
+func TestCountDeleteVersions(t *testing.T) {
+
+	lambdaList := [][]lambda.DeleteFunctionInput{
+		{
+			{
+
+				FunctionName: aws.String("A"),
+				Qualifier:    aws.String("1"),
+			},
+			{
+				FunctionName: aws.String("B"),
+				Qualifier:    aws.String("2"),
+			},
+			{
+				FunctionName: aws.String("C"),
+				Qualifier:    aws.String("3"),
+			},
+			{
+				FunctionName: aws.String("D"),
+				Qualifier:    aws.String("4"),
+			},
+			{
+				FunctionName: aws.String("E"),
+				Qualifier:    aws.String("5"),
+			},
+		},
+		{
+			lambda.DeleteFunctionInput{
+				FunctionName: aws.String("A1"),
+				Qualifier:    aws.String("1"),
+			},
+			lambda.DeleteFunctionInput{
+
+				FunctionName: aws.String("A2"),
+				Qualifier:    aws.String("2"),
+			},
+			lambda.DeleteFunctionInput{
+				FunctionName: aws.String("A3"),
+				Qualifier:    aws.String("3"),
+			},
+		},
+	}
+
+	got := countDeleteVersions(lambdaList)
+
+	want := 8
+
+	if got != want {
+		t.Fatalf("Expected count of versions to be %d but received %d instead", want, got)
+	}
+
+}
+
+func TestCalculateSpaceRemoval(t *testing.T) {
+
+	lambdaList := [][]types.FunctionConfiguration{
+		{
+			types.FunctionConfiguration{
+				CodeSha256:       new(string),
+				FunctionName:     aws.String("A"),
+				Version:          aws.String("1"),
+				CodeSize:         1200,
+				DeadLetterConfig: &types.DeadLetterConfig{},
+				Description:      aws.String("Test A"),
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("B"),
+				Version:      aws.String("2"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("C"),
+				Version:      aws.String("3"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("D"),
+				Version:      aws.String("4"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("E"),
+				Version:      aws.String("5"),
+				CodeSize:     1500,
+			},
+		},
+		{
+			types.FunctionConfiguration{
+				CodeSha256:       new(string),
+				Version:          aws.String("1"),
+				FunctionName:     aws.String("A1"),
+				CodeSize:         1200,
+				DeadLetterConfig: &types.DeadLetterConfig{},
+				Description:      aws.String("Test A"),
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("A2"),
+				Version:      aws.String("2"),
+				CodeSize:     1500,
+			},
+			types.FunctionConfiguration{
+				CodeSha256:   new(string),
+				FunctionName: aws.String("A3"),
+				Version:      aws.String("3"),
+				CodeSize:     1500,
+			},
+		},
+	}
+
+	got := calculateSpaceRemoval(lambdaList)
+
+	want := 11400
+
+	if got != want {
+		t.Fatalf("Expected the size of all versions to be %d but received %d instead", want, got)
+	}
+
+}
+
+func TestCalculateDeletedSpace(t *testing.T) {
+
+	lambdaList := [][]types.FunctionConfiguration{
+		{
+			types.FunctionConfiguration{
+				FunctionName: aws.String("A"),
+				Version:      aws.String("1"),
+				CodeSize:     1200,
+			},
+			types.FunctionConfiguration{
+				FunctionName: aws.String("A"),
+				Version:      aws.String("2"),
+				CodeSize:     1500,
+			},
+		},
+		{
+			types.FunctionConfiguration{
+				FunctionName: aws.String("B"),
+				Version:      aws.String("1"),
+				CodeSize:     1000,
+			},
+		},
+	}
+
+	deleted := []lambda.DeleteFunctionInput{
+		{
+			FunctionName: aws.String("A"),
+			Qualifier:    aws.String("2"),
+		},
+		{
+			FunctionName: aws.String("B"),
+			Qualifier:    aws.String("1"),
+		},
+	}
+
+	got := calculateDeletedSpace(lambdaList, deleted)
+
+	var want int64 = 2500
+
+	if got != want {
+		t.Fatalf("Expected the size of the deleted versions to be %d but received %d instead", want, got)
+	}
+
+}
+
+func TestIsEdgeReplicaError(t *testing.T) {
+
+	replicated := &types.InvalidParameterValueException{
+		Message: aws.String("Lambda was unable to delete arn:aws:lambda:us-east-1:000000000000:function:edge:1 because it is a replicated function."),
+	}
+
+	if !isEdgeReplicaError(fmt.Errorf("operation error Lambda: DeleteFunction, %w", replicated)) {
+		t.Errorf("expected a replicated function error to be classified as an edge replica")
+	}
+
+	for _, err := range []error{
+		nil,
+		&types.InvalidParameterValueException{Message: aws.String("$LATEST version cannot be deleted without deleting the function.")},
+		&types.ResourceConflictException{Message: aws.String("replicated function")},
+	} {
+		if isEdgeReplicaError(err) {
+			t.Errorf("expected %v not to be classified as an edge replica", err)
+		}
+	}
+
+}
+
+func TestCalculateFileSize(t *testing.T) {
+
+	opts := Options{
+		SizeIEC: true,
+	}
+
+	want := "294 MiB"
+	got := CalculateFileSize(308000000, opts)
+
+	if got != want {
+		t.Fatalf("Expected the size output to be %s but received %s instead", want, got)
+	}
+
+	opts.SizeIEC = false
+
+	want2 := "308 MB"
+	got2 := CalculateFileSize(308000000, opts)
+	if got2 != want2 {
+		t.Fatalf("Expected the size output to be %s but received %s instead", want2, got2)
+	}
+
+}
+
+func TestDisplayDuration(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+
+	startTime := time.Now().Add(-time.Second * 30)
+
+	displayDuration(startTime)
+
+	got := buf.String()
+	want := "time=.* level=.* msg=\"Job Duration Time: 30.00"
+	if match, _ := regexp.MatchString(want, got); !match {
+		t.Errorf("displayDuration() = %q, want %q", got, want)
+	}
+	buf.Reset()
+}
+
+// lambdaTestAPI is the Lambda API used by the tests, which also creates the aliases of the test functions.
+type lambdaTestAPI interface {
+	LambdaAPI
+	CreateAlias(ctx context.Context, params *lambda.CreateAliasInput, optFns ...func(*lambda.Options)) (*lambda.CreateAliasOutput, error)
+}
+
+// lambdaBackend is a Lambda API that the tests of the clean-up run against.
+type lambdaBackend struct {
+	name string
+	// new returns a Lambda API with the functions func1, func2, and func3, each with two published versions.
+	new func(t *testing.T) lambdaTestAPI
+}
+
+// lambdaBackends returns the localstack container and the in-memory Lambda API, so that each behavior is tested once against both.
+// The in-memory API returns one item per page to exercise the pagination.
+func lambdaBackends() []lambdaBackend {
+	return []lambdaBackend{
+		{
+			name: "localstack",
+			new:  newLocalstackLambda,
+		},
+		{
+			name: "in-memory",
+			new: func(t *testing.T) lambdaTestAPI {
+				svc := newInMemoryLambda()
+				svc.PageSize = 1
+
+				return svc
+			},
+		},
+	}
+}
+
+// newLocalstackLambda returns a lambda client of the localstack container with the functions created by addFunctions and updateFunctions.
+func newLocalstackLambda(t *testing.T) lambdaTestAPI {
+	ctx := context.Background()
+
+	newNetwork, err := network.New(ctx)
+	if err != nil {
+		t.Errorf("failed to create network: %s", err)
+	}
+	localstackContainer, err := localstack.Run(ctx,
+		"localstack/localstack:3.6",
+		testcontainers.WithEnv(map[string]string{
+			"SERVICES": "lambda"}),
+		testcontainers.WithReuseByName(localstackContainerName),
+		network.WithNetwork([]string{"localstack-network-v2"}, newNetwork),
+	)
+	if err != nil {
+		t.Errorf("failed to start localstack container: %s", err)
+	}
+	// Do not Terminate when using WithReuseByName so the container is reused by later tests.
+
+	svc, err := lambdaClient(ctx, localstackContainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deleteTestFunctions(ctx, svc)
+
+	bf, err := getZipPackage("../../tests/handler.zip")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	_, err = addFunctions(ctx, svc, bf)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	bf2, err := getZipPackage("../../tests/handler2.zip")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	_, err = updateFunctions(ctx, svc, *bf2)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	return svc
+}
+
+// newInMemoryLambda returns an in-memory Lambda API that matches the state created by addFunctions and updateFunctions.
+func newInMemoryLambda() *cleanertest.Lambda {
+	svc := cleanertest.NewLambda()
+
+	for _, name := range []string{"func1", "func2", "func3"} {
+		svc.AddFunction(name, 300, 2)
+	}
+
+	return svc
+}
+
+func TestDeleteLambdaVersionError(t *testing.T) {
+
+	for _, backend := range lambdaBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := backend.new(t)
+
+			deleteList := []lambda.DeleteFunctionInput{
+				{
+					FunctionName: aws.String("test"),
+					Qualifier:    aws.String("1"),
+				},
+			}
+
+			_, _, err := deleteLambdaVersion(ctx, svc, newWorkerPool(1, 0), nil, deleteList)
+			if err == nil {
+				t.Errorf("expected an error to be returned but received %v", err)
+			}
+		})
+	}
+
+}
+
+func TestDeleteLambdaVersionThrottled(t *testing.T) {
+
+	ctx := context.Background()
+	svc := newInMemoryLambda()
+
+	svc.Throttle(cleanertest.OperationDeleteFunction, 1)
+
+	deleteList := []lambda.DeleteFunctionInput{
+		{
+			FunctionName: aws.String("func1"),
+			Qualifier:    aws.String("1"),
+		},
+		{
+			FunctionName: aws.String("func1"),
+			Qualifier:    aws.String("2"),
+		},
+	}
+
+	deleted, _, err := deleteLambdaVersion(ctx, svc, newWorkerPool(1, 0), nil, deleteList)
+	if err == nil {
+		t.Errorf("expected an error to be returned for the throttled deletion but received %v", err)
+	}
+
+	if len(deleted) != 1 || *deleted[0].Qualifier != "2" {
+		t.Errorf("expected version 2 to be deleted after the throttled deletion but received %v", deleted)
+	}
+
+}
+
+func TestDeleteLambdaVersion(t *testing.T) {
+
+	for _, backend := range lambdaBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := backend.new(t)
+
+			deleteList := []lambda.DeleteFunctionInput{
+				{
+					FunctionName: aws.String("func1"),
+					Qualifier:    aws.String("2"),
+				},
+			}
+
+			deleted, _, err := deleteLambdaVersion(ctx, svc, newWorkerPool(1, 0), nil, deleteList)
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if len(deleted) != 1 {
+				t.Errorf("expected 1 deleted version to be returned but received %v", len(deleted))
+			}
+
+			versions, err := listFunctionVersions(ctx, svc, "func1")
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			slices.Sort(versions)
+			if !slices.Equal(versions, []string{"$LATEST", "1"}) {
+				t.Errorf("expected $LATEST and version 1 to remain but received %v", versions)
+			}
+		})
+	}
+
+}
+
+func TestGetAllLambdas(t *testing.T) {
+
+	for _, backend := range lambdaBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := backend.new(t)
+
+			lambdaListResult, err := getAllLambdas(ctx, svc, []string{})
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			// The localstack container is shared with other tests, so the functions are counted rather than assumed.
+			result, err := listFunctions(ctx, svc)
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if len(lambdaListResult) != result || result < 3 {
+				t.Errorf("expected %d functions to be returned but received %v", result, len(lambdaListResult))
+			}
+
+			lambdaListResult2, err := getAllLambdas(ctx, svc, []string{"func1"})
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if len(lambdaListResult2) != 1 {
+				t.Errorf("Scenario 2: expected 1 functions to be returned but received %v", len(lambdaListResult2))
+			}
+
+			lambdaListResult3, err := getAllLambdas(ctx, svc, []string{"func22"})
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if len(lambdaListResult3) != 0 {
+				t.Errorf("Scenario 3: expected 0 functions to be returned but received %v", len(lambdaListResult3))
+			}
+		})
+	}
+
+}
+
+func TestGetAllLambdasThrottled(t *testing.T) {
+
+	ctx := context.Background()
+	svc := newInMemoryLambda()
+
+	svc.Throttle(cleanertest.OperationListFunctions, 1)
+
+	_, err := getAllLambdas(ctx, svc, []string{})
+	if err == nil {
+		t.Errorf("expected an error to be returned but received %v", err)
+	}
+
+}
+
+func TestGetAllLambdaVersion(t *testing.T) {
+
+	for _, backend := range lambdaBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := backend.new(t)
+
+			opts := Options{
+				Region:            "us-east-1",
+				Retain:            0,
+				DryRun:            true,
+				SkipAliases:       false,
+				MoreLambdaDetails: true,
+				SizeIEC:           false,
+			}
+
+			versions, _, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
+				FunctionName: aws.String("func1"),
+			}, opts, nil)
+			if err != nil {
+				t.Fatalf("expected no error to be returned but received %v", err)
+			}
+
+			if len(versions) != 3 {
+				t.Fatalf("expected 3 versions to be returned but received %v", len(versions))
+			}
+
+			if *versions[len(versions)-1].Version != "$LATEST" {
+				t.Errorf("expected $LATEST to be listed last but received %v", *versions[len(versions)-1].Version)
+			}
+		})
+	}
+
+}
+
+func TestGetAllLambdaVersionWithAlias(t *testing.T) {
+
+	for _, backend := range lambdaBackends() {
+		t.Run(backend.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := backend.new(t)
+
+			opts := Options{
+				Region:            "us-east-1",
+				Retain:            0,
+				DryRun:            true,
+				SkipAliases:       true,
+				MoreLambdaDetails: true,
+				SizeIEC:           false,
+			}
+
+			_, err := publishAlias(ctx, svc, "func1", "DEMO", "2")
+			if err != nil {
+				t.Fatalf("expected no error to be returned but received %v", err)
+			}
+
+			count, aliases, err := listFunctionAliases(ctx, svc, "func1", "2")
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if count != 1 {
+				t.Errorf("expected 1 alias to be returned but received %v %v", count, aliases)
+			}
+
+			versions, _, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
+				FunctionName: aws.String("func1"),
+				FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1"),
+			}, opts, nil)
+			if err != nil {
+				t.Errorf("expected no error to be returned but received %v", err)
+			}
+
+			if len(versions) != 2 {
+				t.Errorf("expected 2 versions to be returned but received %v", len(versions))
+			}
+
+			for _, v := range versions {
+				if *v.Version == "2" {
+					t.Errorf("expected version 2 of the alias DEMO to be skipped but received %v", *v.Version)
+				}
+			}
+
+			_, _, err = getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
+				FunctionName: aws.String("func22"),
+				FunctionArn:  aws.String("arn:aws:lambda:us-east-1:000000000000:function:func22"),
+			}, opts, nil)
+			if err == nil {
+				t.Errorf("expected an error to be returned but received %v", err)
+			}
+		})
+	}
+
+}
+
+func TestGetAllLambdaVersionListAliasesError(t *testing.T) {
+
+	ctx := context.Background()
+	svc := newInMemoryLambda()
+
+	opts := Options{
+		Region:      "us-east-1",
+		DryRun:      true,
+		SkipAliases: true,
+	}
+
+	svc.Fail(cleanertest.OperationListAliases, errors.New("access denied"))
+
+	_, _, err := getAllLambdaVersion(ctx, svc, types.FunctionConfiguration{
+		FunctionName: aws.String("func1"),
+	}, opts, nil)
+	if err == nil {
+		t.Errorf("expected an error to be returned but received %v", err)
+	}
+
+}
+
+// lambdaClient returns a lambda client configured to use the localstack containers
+func lambdaClient(ctx context.Context, l *localstack.LocalStackContainer) (*lambda.Client, error) {
+	mappedPort, err := l.MappedPort(ctx, nat.Port("4566/tcp"))
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return nil, err
+	}
+	defer provider.Close()
+
+	host, err := provider.DaemonHost(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(
+		context.TODO(),
+		config.WithRegion("us-east-1"),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("aaaa", "bbbb", "cccc")),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client := lambda.NewFromConfig(awsCfg, func(o *lambda.Options) {
+		o.BaseEndpoint = aws.String(fmt.Sprintf("http://%s:%d", host, mappedPort.Int()))
+	})
+
+	return client, nil
+}
+
+// deleteTestFunctions removes func1, func2, func3 from LocalStack so tests can start from a clean state when reusing the container.
+func deleteTestFunctions(ctx context.Context, svc *lambda.Client) {
+	for _, name := range []string{"func1", "func2", "func3"} {
+		_, _ = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{FunctionName: aws.String(name)})
+	}
+}
+
+func addFunctions(ctx context.Context, svc *lambda.Client, zipPackage *bytes.Buffer) (string, error) {
+
+	list := []lambda.CreateFunctionInput{
+		{
+			Code: &types.FunctionCode{
+				ZipFile: zipPackage.Bytes(),
+			},
+			Description:  aws.String("func1"),
+			FunctionName: aws.String("func1"),
+			Handler:      aws.String("index.handler"),
+			Role:         aws.String("arn:aws:iam::123456789012:role/lambda-role"),
+			Runtime:      types.RuntimeNodejs18x,
+			Publish:      true,
+		},
+		{
+			Code: &types.FunctionCode{
+				ZipFile: zipPackage.Bytes(),
+			},
+			Description:  aws.String("func2"),
+			FunctionName: aws.String("func2"),
+			Handler:      aws.String("index.handler"),
+			Role:         aws.String("arn:aws:iam::123456789012:role/lambda-role"),
+			Runtime:      types.RuntimeNodejs18x,
+			Publish:      true,
+		},
+		{
+			Code: &types.FunctionCode{
+				ZipFile: zipPackage.Bytes(),
+			},
+			Description:  aws.String("func3"),
+			FunctionName: aws.String("func3"),
+			Handler:      aws.String("index.handler"),
+			Role:         aws.String("arn:aws:iam::123456789012:role/lambda-role"),
+			Runtime:      types.RuntimeNodejs18x,
+			Publish:      true,
+		},
+	}
+
+	var result string
+
+	for _, input := range list {
+		var state types.State
+		item := input
+		output, err := svc.CreateFunction(ctx, &item)
+		if err != nil {
+			var resConflict *types.ResourceConflictException
+			if errors.As(err, &resConflict) {
+				log.Printf("Function %v already exists.\n", *input.FunctionName)
+				state = types.StateActive
+			} else {
+				log.Panicf("Couldn't create function %v. Here's why: %v\n", *input.FunctionName, err)
+			}
+		} else {
+			waiter := lambda.NewFunctionActiveV2Waiter(svc)
+			funcOutput, err := waiter.WaitForOutput(context.TODO(), &lambda.GetFunctionInput{
+				FunctionName: aws.String(*input.FunctionName)}, 2*time.Minute)
+			if err != nil {
+				log.Panicf("Couldn't wait for function %v to be active. Here's why: %v\n", *input.FunctionName, err)
+			} else {
+				state = funcOutput.Configuration.State
+			}
+		}
+
+		if output != nil {
+			fmt.Println("Function ARN: ", *output.FunctionArn)
+		}
+
+		result += fmt.Sprintf("Function %v is %v\n", *input.FunctionName, state)
+	}
+
+	return fmt.Sprintf("Result: %v", result), nil
+}
+
+func listFunctions(ctx context.Context, svc lambdaTestAPI) (int, error) {
+
+	var count int
+
+	p := lambda.NewListFunctionsPaginator(svc, &lambda.ListFunctionsInput{})
+	for p.HasMorePages() {
+		output, err := p.NextPage(ctx)
+		if err != nil {
+			return 0, err
+		}
+
+		count += len(output.Functions)
+	}
+
+	return count, nil
+
+}
+
+func updateFunctions(ctx context.Context, svc *lambda.Client, zipPackage bytes.Buffer) (string, error) {
+
+	list := []lambda.UpdateFunctionCodeInput{
+		{
+			FunctionName: aws.String("func1"),
+			ZipFile:      zipPackage.Bytes(),
+			Publish:      true,
+		},
+		{
+			FunctionName: aws.String("func2"),
+			ZipFile:      zipPackage.Bytes(),
+			Publish:      true,
+		},
+		{
+			FunctionName: aws.String("func3"),
+			ZipFile:      zipPackage.Bytes(),
+			Publish:      true,
+		},
+	}
+
+	var result string
+
+	for _, input := range list {
+		var state types.State
+		item := input
+		_, err := svc.UpdateFunctionCode(ctx, &item)
+		if err != nil {
+			var resConflict *types.ResourceConflictException
+			if errors.As(err, &resConflict) {
+				log.Printf("Function %v already exists.\n", "test")
+				state = types.StateActive
+			} else {
+				log.Panicf("Couldn't create function %v. Here's why: %v\n", "test", err)
+			}
+		} else {
+			waiter := lambda.NewFunctionActiveV2Waiter(svc)
+			funcOutput, err := waiter.WaitForOutput(context.TODO(), &lambda.GetFunctionInput{
+				FunctionName: aws.String(*input.FunctionName)}, 2*time.Minute)
+			if err != nil {
+				log.Panicf("Couldn't wait for function %v to be active. Here's why: %v\n", "test", err)
+			} else {
+				state = funcOutput.Configuration.State
+			}
+		}
+
+		result += fmt.Sprintf("Function %v was updated and the state is  %v\n", *input.FunctionName, state)
+	}
+
+	return fmt.Sprintf("Result: %v", result), nil
+}
+
+func publishAlias(ctx context.Context, svc lambdaTestAPI, functionName string, aliasName string, version string) (string, error) {
+
+	input := lambda.CreateAliasInput{
+		FunctionName:    aws.String(functionName),
+		FunctionVersion: aws.String(version),
+		Name:            aws.String(aliasName),
+		Description:     aws.String("Alias Test"),
+	}
+
+	_, err := svc.CreateAlias(ctx, &input)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+
+	return fmt.Sprintf("Alias %v was created for function %v", aliasName, functionName), nil
+}
+
+func getZipPackage(zipFile string) (*bytes.Buffer, error) {
+	_, err := os.Stat(zipFile)
+	if err != nil {
+		return nil, err
+	}
+
+	zipPackage, err := os.Open(zipFile)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zipPackage.Close()
+
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(zipPackage)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf, err
+
+}
+
+func listFunctionVersions(ctx context.Context, svc lambdaTestAPI, funcName string) ([]string, error) {
+
+	var versions []string
+
+	p := lambda.NewListVersionsByFunctionPaginator(svc, &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String(funcName),
+	})
+	for p.HasMorePages() {
+		output, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, version := range output.Versions {
+			versions = append(versions, *version.Version)
+		}
+	}
+
+	return versions, nil
+}
+
+func listFunctionAliases(ctx context.Context, svc lambdaTestAPI, funcName, funcVersion string) (int, []string, error) {
+
+	output, err := svc.ListAliases(ctx, &lambda.ListAliasesInput{
+		FunctionName:    aws.String(funcName),
+		FunctionVersion: aws.String(funcVersion),
+	})
+
+	if err != nil {
+		return 0, []string{}, nil
+	}
+
+	var aliases []string
+	for _, alias := range output.Aliases {
+		aliases = append(aliases, *alias.Name)
+	}
+
+	return len(output.Aliases), aliases, nil
+}
+
+func TestExcludeIntegrationsRateLimit(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.AddFunction("func4", 300, 4)
+
+	var versions []types.FunctionConfiguration
+	for _, version := range svc.Versions("func4") {
+		versions = append(versions, types.FunctionConfiguration{FunctionName: aws.String("func4"), Version: aws.String(version)})
+	}
+
+	start := time.Now()
+
+	output, skipped, err := excludeIntegrations(context.Background(), svc, newWorkerPool(1, 20), "func4", versions)
+	if err != nil || skipped != 0 || len(output) != len(versions) {
+		t.Fatalf("expected no version to be protected but received %v %d %v", output, skipped, err)
+	}
+
+	// The invoke configs and the 4 policies are requested at 20 requests per second.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the requests to be rate limited but they completed in %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := svc.Calls(cleanertest.OperationGetPolicy)
+
+	_, _, err = excludeIntegrations(ctx, svc, newWorkerPool(1, 20), "func4", versions)
+	if !errors.Is(err, context.Canceled) || svc.Calls(cleanertest.OperationGetPolicy) != calls {
+		t.Errorf("expected a cancelled scan to stop before requesting the policies but received %v", err)
+	}
+
+}
+
+func TestUpdateInProgressRateLimit(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	pool := newWorkerPool(1, 20)
+	opts := Options{SkipUpdating: true}
+
+	start := time.Now()
+
+	for _, name := range []string{"func1", "func2", "func3", "func1"} {
+		reason, err := updateInProgress(context.Background(), svc, pool, types.FunctionConfiguration{FunctionName: aws.String(name)}, opts, time.Now())
+		if err != nil || reason != "" {
+			t.Fatalf("expected %s not to be in progress but received %q %v", name, reason, err)
+		}
+	}
+
+	// The 4 configurations are requested at 20 requests per second.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the requests to be rate limited but they completed in %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := svc.Calls(cleanertest.OperationGetFunction)
+
+	_, err := updateInProgress(ctx, svc, pool, types.FunctionConfiguration{FunctionName: aws.String("func1")}, opts, time.Now())
+	if !errors.Is(err, context.Canceled) || svc.Calls(cleanertest.OperationGetFunction) != calls {
+		t.Errorf("expected a cancelled check to stop before requesting the configuration but received %v", err)
+	}
+
+}
+
+func TestPruneAliasesRateLimit(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.AddFunction("func4", 300, 4)
+
+	for i, version := range svc.Versions("func4") {
+		err := svc.AddAlias("func4", fmt.Sprintf("pr-%d", i), version)
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	item := types.FunctionConfiguration{FunctionName: aws.String("func4")}
+	opts := Options{
+		DryRun:         true,
+		PruneAliases:   []string{"pr-*"},
+		AliasOlderThan: time.Hour,
+	}
+
+	start := time.Now()
+
+	_, err := pruneAliases(context.Background(), svc, newWorkerPool(1, 20), item, opts, time.Now())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	// The versions of the 4 aliases are requested at 20 requests per second.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected the requests to be rate limited but they completed in %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := svc.Calls(cleanertest.OperationGetFunction)
+
+	_, err = pruneAliases(ctx, svc, newWorkerPool(1, 20), item, opts, time.Now())
+	if !errors.Is(err, context.Canceled) || svc.Calls(cleanertest.OperationGetFunction) != calls {
+		t.Errorf("expected a cancelled pruning to stop before requesting the versions but received %v", err)
+	}
+
+}
+
+func TestExcludeAliasRollback(t *testing.T) {
+
+	var versions []types.FunctionConfiguration
+
+	for _, version := range []string{"9", "7", "6", "4", "3", "1", "$LATEST"} {
+		versions = append(versions, types.FunctionConfiguration{FunctionName: aws.String("func1"), Version: aws.String(version)})
+	}
+
+	aliases := []types.AliasConfiguration{
+		{Name: aws.String("prod"), FunctionVersion: aws.String("7")},
+		{Name: aws.String("beta"), FunctionVersion: aws.String("9")},
+		{Name: aws.String("dev"), FunctionVersion: aws.String("$LATEST")},
+	}
+
+	var got []string
+	for _, item := range excludeAliasRollback(versions, aliases, 2) {
+		got = append(got, *item.Version)
+	}
+
+	// The versions the aliases point to are retained along with their rollback windows.
+	want := []string{"3", "1", "$LATEST"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to be returned but received %v", want, got)
+	}
+
+	if len(excludeAliasRollback(versions, nil, 2)) != len(versions) {
+		t.Errorf("expected the versions to be returned unchanged without aliases")
+	}
+
+}
@@ -0,0 +1,401 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+/*
+Package cleaner removes former versions of AWS Lambda functions.
+
+The package contains the clean-up logic of the glc CLI so that it can be embedded in other tools.
+A Cleaner is created with a LambdaAPI client, such as a *lambda.Client, and the Options of the clean-up.
+
+	svc := lambda.NewFromConfig(cfg)
+
+	summary, err := cleaner.New(svc, cleaner.Options{
+		Region: "us-east-1",
+		Retain: 2,
+	}).Run(ctx)
+*/
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrInterrupted is returned by Run when the context is cancelled before the clean-up completes.
+// The deletions in progress are allowed to complete and the Summary contains the work completed.
+var ErrInterrupted = errors.New("the clean-up process was interrupted before it completed")
+
+// LambdaAPI is the subset of the AWS Lambda API used by the Cleaner. A *lambda.Client satisfies the interface.
+type LambdaAPI interface {
+	lambda.ListFunctionsAPIClient
+	lambda.ListVersionsByFunctionAPIClient
+	lambda.ListAliasesAPIClient
+	lambda.ListFunctionEventInvokeConfigsAPIClient
+	ListTags(ctx context.Context, params *lambda.ListTagsInput, optFns ...func(*lambda.Options)) (*lambda.ListTagsOutput, error)
+	GetPolicy(ctx context.Context, params *lambda.GetPolicyInput, optFns ...func(*lambda.Options)) (*lambda.GetPolicyOutput, error)
+	GetFunction(ctx context.Context, params *lambda.GetFunctionInput, optFns ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error)
+	DeleteAlias(ctx context.Context, params *lambda.DeleteAliasInput, optFns ...func(*lambda.Options)) (*lambda.DeleteAliasOutput, error)
+	DeleteFunction(ctx context.Context, params *lambda.DeleteFunctionInput, optFns ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error)
+}
+
+var _ LambdaAPI = (*lambda.Client)(nil)
+
+// Options are the settings of a clean-up.
+type Options struct {
+	// Region is the AWS region of the Lambda API client. It is used for reporting and to validate a resumed state file.
+	Region string
+	// Retain is the number of versions to retain excluding $LATEST. A value lower than 1 retains a single version.
+	Retain int8
+	// DryRun previews the clean-up without deleting any version.
+	DryRun bool
+	// SkipAliases skips the versions that have an alias attached.
+	SkipAliases bool
+	// RetainBehindAlias is the number of versions immediately older than the version of each alias that are retained, so that the alias can be rolled back. A value of 0 disables the rollback window.
+	RetainBehindAlias int8
+	// SkipUpdating skips the Lambdas that are being deployed, such as a Lambda in the Pending state or with an update in progress.
+	SkipUpdating bool
+	// UpdateGracePeriod skips the Lambdas modified less than the duration ago, so that a deployment that just completed can be rolled back. A value of 0 disables the grace period.
+	UpdateGracePeriod time.Duration
+	// PruneAliases are the glob patterns of the names of the aliases deleted before the versions are cleaned, such as pr-*. The patterns use the syntax of path.Match.
+	PruneAliases []string
+	// AliasOlderThan limits the pruning to the aliases that point to a version last modified more than the duration ago. A value of 0 disables the limit.
+	AliasOlderThan time.Duration
+	// MoreLambdaDetails reports the number of versions removed for each Lambda.
+	MoreLambdaDetails bool
+	// SizeIEC reports sizes in IEC units.
+	SizeIEC bool
+	// Verify rescans each Lambda after the clean-up to confirm the deleted versions are removed.
+	Verify bool
+	// Concurrency is the maximum number of Lambdas processed at the same time. A value lower than 1 processes a single Lambda at a time.
+	Concurrency int
+	// RateLimit is the maximum number of Lambdas scanned or versions deleted per second. A value of 0 disables the limit.
+	RateLimit float64
+	// StateFile is a file to record each completed deletion and the planned remainder of the clean-up.
+	StateFile string
+	// ResumeFile is a state file of an interrupted clean-up to resume. The progress is appended to the same file.
+	ResumeFile string
+	// Functions limits the clean-up to the named Lambdas. All the Lambdas of the region are targeted if empty.
+	Functions []string
+	// Include limits the clean-up to the Lambdas with a name that matches one of the glob patterns. All the Lambdas are targeted if empty.
+	Include []string
+	// Exclude skips the Lambdas with a name that matches one of the glob patterns.
+	Exclude []string
+	// Protected are the names of Lambdas that are never cleaned, including when they are named in Functions or in a resumed state file.
+	Protected []string
+	// OlderThan limits the deletion to the versions last modified more than the duration ago. A value of 0 disables the limit.
+	OlderThan time.Duration
+	// Overrides are the settings of individual Lambdas, keyed by the name of the Lambda. They take precedence over the Options.
+	Overrides map[string]Override
+	// KeepDescription are the patterns of the descriptions of versions that are always retained, regardless of Retain.
+	// A pattern uses the syntax of path.Match, or is a regular expression when it is wrapped in slashes, such as /^release-/.
+	KeepDescription []string
+	// ProtectIntegrations skips the versions with a version-specific resource-based policy or event invoke configuration, such as the versions granted to an S3 notification or configured with a destination.
+	ProtectIntegrations bool
+	// ProtectedVersions are the Lambda versions that are never deleted, such as the versions referenced by CloudFormation stacks.
+	// A version is identified by its ARN, or by the name of the Lambda qualified with the version such as my-function:3.
+	ProtectedVersions []string
+	// TagOverrides applies the glc:retain, glc:skip, and glc:max-age tags of each Lambda. The Overrides take precedence over the tags.
+	TagOverrides bool
+}
+
+// Override contains the settings of a single Lambda that take precedence over the Options of the clean-up.
+type Override struct {
+	// Retain is the number of versions to retain excluding $LATEST.
+	Retain *int8
+	// SkipAliases skips the versions that have an alias attached.
+	SkipAliases *bool
+	// OlderThan limits the deletion to the versions last modified more than the duration ago. A value of 0 keeps the value of the Options.
+	OlderThan time.Duration
+}
+
+// forFunction returns a copy of the Options with the override of the Lambda applied, if any.
+func (o Options) forFunction(name string) Options {
+	override, ok := o.Overrides[name]
+	if !ok {
+		return o
+	}
+
+	if override.Retain != nil {
+		o.Retain = *override.Retain
+	}
+
+	if override.SkipAliases != nil {
+		o.SkipAliases = *override.SkipAliases
+	}
+
+	if override.OlderThan != 0 {
+		o.OlderThan = override.OlderThan
+	}
+
+	return o
+}
+
+// Summary contains the totals of a clean-up.
+type Summary struct {
+	// Lambdas is the number of Lambdas that went through the clean-up pipeline.
+	Lambdas int
+	// Storage is the total size of the versions scanned.
+	Storage int64
+	// Planned is the number of versions selected for deletion.
+	Planned int
+	// PlannedSize is the total size of the versions selected for deletion.
+	PlannedSize int64
+	// Deleted is the number of versions deleted.
+	Deleted int
+	// Freed is the total size of the versions deleted.
+	Freed int64
+	// EdgeReplicas is the number of versions that were not deleted because they are replicated by Lambda@Edge. They are not counted as failures.
+	EdgeReplicas int
+	// Integrations is the number of versions that were not deleted because a resource-based policy or an event invoke configuration is attached to the version.
+	Integrations int
+	// Updating is the number of Lambdas skipped because they are being deployed or were modified within the UpdateGracePeriod.
+	Updating int
+	// AliasesPruned is the number of aliases deleted, or to be deleted in a dry run, by PruneAliases.
+	AliasesPruned int
+	// Failed is the number of Lambdas that failed to complete the clean-up.
+	Failed int
+	// Interrupted is the number of Lambdas that did not complete the clean-up because the context was cancelled.
+	Interrupted int
+}
+
+// add updates the running totals with the result of a single Lambda.
+func (s *Summary) add(result functionResult) {
+	s.Lambdas++
+	s.Storage = s.Storage + result.storage
+	s.Planned = s.Planned + result.planned
+	s.PlannedSize = s.PlannedSize + result.plannedSize
+	s.Deleted = s.Deleted + result.deleted
+	s.Freed = s.Freed + result.freed
+	s.EdgeReplicas = s.EdgeReplicas + result.edgeReplicas
+	s.Integrations = s.Integrations + result.integrations
+	s.AliasesPruned = s.AliasesPruned + result.aliasesPruned
+
+	if result.updating {
+		s.Updating++
+	}
+
+	if result.err != nil {
+		s.Failed++
+	}
+
+	if result.interrupted {
+		s.Interrupted++
+	}
+}
+
+// Cleaner removes the former versions of AWS Lambda functions.
+type Cleaner struct {
+	svc  LambdaAPI
+	opts Options
+}
+
+// New returns a Cleaner that uses the provided Lambda API client.
+func New(svc LambdaAPI, opts Options) *Cleaner {
+	return &Cleaner{
+		svc:  svc,
+		opts: opts,
+	}
+}
+
+// Run executes the clean-up and returns its totals.
+// The Lambdas are streamed one at a time through the list, plan, delete, and report stages so that memory usage remains flat regardless of the number of versions.
+// If the context is cancelled, no new deletions are started and ErrInterrupted is returned along with the totals of the work completed.
+func (c *Cleaner) Run(ctx context.Context) (Summary, error) {
+	var (
+		summary    Summary
+		lambdaList []types.FunctionConfiguration
+	)
+
+	startTime := time.Now()
+	opts := c.opts
+
+	keepVersion, err := newDescriptionMatcher(opts.KeepDescription)
+	if err != nil {
+		return summary, err
+	}
+
+	protectedVersion, err := newProtectedVersionMatcher(opts.ProtectedVersions)
+	if err != nil {
+		return summary, err
+	}
+
+	run := execution{
+		keepVersions: []versionPredicate{keepVersion, protectedVersion},
+	}
+
+	err = validateAliasPatterns(opts.PruneAliases)
+	if err != nil {
+		return summary, err
+	}
+
+	state, err := openCheckpoint(opts)
+	if err != nil {
+		return summary, err
+	}
+	defer state.close()
+
+	pool := newWorkerPool(opts.Concurrency, opts.RateLimit)
+
+	if state.resumed() {
+		if state.region != opts.Region {
+			return summary, fmt.Errorf("the state file was created for the region %s but the region %s was provided", state.region, opts.Region)
+		}
+
+		log.Info("******** RESUMING CLEAN-UP FROM " + opts.ResumeFile + " ********")
+
+		for _, name := range state.lambdas {
+			lambdaList = append(lambdaList, types.FunctionConfiguration{
+				FunctionName: aws.String(name),
+			})
+		}
+
+		lambdaList = excludeProtected(lambdaList, opts.Protected)
+
+		// The tags are read again as a resumed Lambda without a recorded plan is planned from scratch.
+		if opts.TagOverrides {
+			lambdaList, opts.Overrides, err = applyTagOverrides(ctx, c.svc, pool, lambdaList, opts.Overrides)
+			if err != nil {
+				if ctx.Err() != nil {
+					return summary, ErrInterrupted
+				}
+
+				return summary, err
+			}
+		}
+	} else {
+		log.Info("Scanning AWS environment in " + opts.Region)
+
+		lambdaList, err = getAllLambdas(ctx, c.svc, opts.Functions)
+		if err != nil {
+			if ctx.Err() != nil {
+				return summary, ErrInterrupted
+			}
+
+			return summary, fmt.Errorf("failed to retrieve the Lambda list: %w", err)
+		}
+
+		lambdaList, err = filterLambdas(lambdaList, opts.Include, opts.Exclude)
+		if err != nil {
+			return summary, err
+		}
+
+		lambdaList = excludeProtected(lambdaList, opts.Protected)
+
+		if opts.TagOverrides {
+			lambdaList, opts.Overrides, err = applyTagOverrides(ctx, c.svc, pool, lambdaList, opts.Overrides)
+			if err != nil {
+				if ctx.Err() != nil {
+					return summary, ErrInterrupted
+				}
+
+				return summary, err
+			}
+		}
+	}
+
+	log.Info("............")
+
+	if len(lambdaList) == 0 {
+		log.Info("No lambdas found in ", opts.Region)
+		displayDuration(startTime)
+
+		return summary, nil
+	}
+
+	log.Info(len(lambdaList), " Lambdas identified")
+	log.Info("**************************")
+	log.Info("Initiating clean-up process. This may take a few minutes....")
+
+	// Sort the list so that the output is deterministic regardless of the order in which the Lambdas complete the pipeline.
+	sort.Slice(lambdaList, func(i, j int) bool {
+		return *lambdaList[i].FunctionName < *lambdaList[j].FunctionName
+	})
+
+	if !state.resumed() {
+		names := make([]string, 0, len(lambdaList))
+		for _, item := range lambdaList {
+			names = append(names, *item.FunctionName)
+		}
+
+		err = state.start(opts.Region, names)
+		if err != nil {
+			return summary, err
+		}
+	}
+
+	summary, err = runCleanPipeline(ctx, c.svc, pool, lambdaList, opts, run, state)
+
+	log.Info("............")
+
+	if ctx.Err() != nil {
+		log.Warn("******** CLEAN-UP INTERRUPTED ********")
+		log.Info("Lambdas not processed: ", len(lambdaList)-summary.Lambdas+summary.Interrupted)
+	}
+
+	log.Info("Current storage size: ", CalculateFileSize(uint64(summary.Storage), opts))
+
+	if summary.Integrations > 0 {
+		log.Info("Versions protected by integrations: ", summary.Integrations)
+	}
+
+	if summary.Updating > 0 {
+		log.Info("Lambdas skipped as they are being deployed: ", summary.Updating)
+	}
+
+	if opts.DryRun {
+		if summary.AliasesPruned > 0 {
+			log.Info(fmt.Sprintf("%d aliases will be pruned in an actual execution.", summary.AliasesPruned))
+		}
+
+		log.Info(fmt.Sprintf("%d unique versions will be removed in an actual execution.", summary.Planned))
+		log.Info(CalculateFileSize(uint64(summary.PlannedSize), opts) + " of storage space will be removed in an actual execution.")
+	} else {
+		if summary.AliasesPruned > 0 {
+			log.Info("Total aliases pruned: ", summary.AliasesPruned)
+		}
+
+		log.Info("Total versions removed: ", summary.Deleted)
+
+		if summary.EdgeReplicas > 0 {
+			log.Info("Versions protected as edge replicas: ", summary.EdgeReplicas)
+		}
+
+		if ctx.Err() != nil {
+			log.Info("Pending versions not removed: ", summary.Planned-summary.Deleted-summary.EdgeReplicas)
+		}
+
+		log.Info("Total space freed up: ", (CalculateFileSize(uint64(summary.Freed), opts)))
+		log.Info("Post clean-up storage size: ", CalculateFileSize(uint64(summary.Storage-summary.Freed), opts))
+		log.Info("*********************************************")
+	}
+
+	displayDuration(startTime)
+
+	if ctx.Err() != nil {
+		return summary, ErrInterrupted
+	}
+
+	return summary, err
+}
+
+// openCheckpoint returns the checkpoint used to record the progress of the clean-up.
+// A nil checkpoint is returned if neither a state file nor a resume file is provided.
+func openCheckpoint(opts Options) (*checkpoint, error) {
+	if opts.ResumeFile != "" {
+		return resumeCheckpoint(opts.ResumeFile)
+	}
+
+	if opts.StateFile != "" {
+		return newCheckpoint(opts.StateFile)
+	}
+
+	return nil, nil
+}
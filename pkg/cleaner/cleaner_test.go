@@ -0,0 +1,659 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/karl-cardenas-coding/go-lambda-cleanup/v2/pkg/cleaner/cleanertest"
+)
+
+var _ LambdaAPI = (*cleanertest.Lambda)(nil)
+
+func TestRunDryRun(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	summary, err := New(svc, Options{
+		Region: "us-east-1",
+		Retain: 1,
+		DryRun: true,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 3 || summary.Planned != 3 || summary.PlannedSize != 900 {
+		t.Errorf("expected 3 versions of 900 bytes to be planned across 3 Lambdas but received %+v", summary)
+	}
+
+	if summary.Deleted != 0 || svc.Calls(cleanertest.OperationDeleteFunction) != 0 {
+		t.Errorf("expected no version to be deleted in a dry run but received %+v", summary)
+	}
+
+}
+
+func TestRun(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	err := svc.AddAlias("func2", "live", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	summary, err := New(svc, Options{
+		Region:      "us-east-1",
+		Retain:      1,
+		SkipAliases: true,
+		Verify:      true,
+		Concurrency: 2,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Deleted != 2 || summary.Freed != 600 {
+		t.Errorf("expected 2 versions of 600 bytes to be deleted but received %+v", summary)
+	}
+
+	for name, want := range map[string]int{"func1": 1, "func2": 2, "func3": 1} {
+		if got := len(svc.Versions(name)); got != want {
+			t.Errorf("expected %d versions of %s to remain but received %d", want, name, got)
+		}
+	}
+
+}
+
+func TestRunDeleteError(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.Throttle(cleanertest.OperationDeleteFunction, 1)
+
+	summary, err := New(svc, Options{
+		Region:      "us-east-1",
+		Retain:      1,
+		Concurrency: 1,
+	}).Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error to be returned but received %v", err)
+	}
+
+	if summary.Failed != 1 || summary.Deleted != 2 {
+		t.Errorf("expected 1 failed Lambda and 2 deleted versions but received %+v", summary)
+	}
+
+}
+
+func TestRunListError(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.Fail(cleanertest.OperationListFunctions, errors.New("access denied"))
+
+	_, err := New(svc, Options{
+		Region: "us-east-1",
+	}).Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error to be returned but received %v", err)
+	}
+
+}
+
+func TestRunOverrides(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.AddFunction("func1", 300, 3)
+	svc.AddFunction("func3", 300, 1)
+
+	err := svc.AddAlias("func2", "live", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	err = svc.SetLastModified("func3", "1", time.Now().Add(-48*time.Hour))
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	summary, err := New(svc, Options{
+		Region: "us-east-1",
+		Retain: 1,
+		Overrides: map[string]Override{
+			"func1": {Retain: aws.Int8(3)},
+			"func2": {SkipAliases: aws.Bool(true)},
+			"func3": {OlderThan: 24 * time.Hour},
+		},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Deleted != 3 {
+		t.Errorf("expected 3 versions to be deleted but received %+v", summary)
+	}
+
+	for name, want := range map[string]int{"func1": 3, "func2": 2, "func3": 2} {
+		if got := len(svc.Versions(name)); got != want {
+			t.Errorf("expected %d versions of %s to remain but received %d", want, name, got)
+		}
+	}
+
+}
+
+func TestRunProtected(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	summary, err := New(svc, Options{
+		Region:    "us-east-1",
+		Retain:    1,
+		Protected: []string{"func2"},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 2 || len(svc.Versions("func2")) != 2 {
+		t.Errorf("expected func2 to be left untouched but received %+v", summary)
+	}
+
+	summary, err = New(svc, Options{
+		Region:    "us-east-1",
+		Retain:    1,
+		Functions: []string{"func2"},
+		Protected: []string{"func2"},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 0 || svc.Calls(cleanertest.OperationListVersionsByFunction) != 2 {
+		t.Errorf("expected a protected Lambda of the custom list to be skipped but received %+v", summary)
+	}
+
+}
+
+func TestRunInvalidKeepDescription(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	_, err := New(svc, Options{
+		Region:          "us-east-1",
+		KeepDescription: []string{"release-["},
+	}).Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error to be returned for a malformed pattern but received %v", err)
+	}
+
+	if svc.Calls(cleanertest.OperationListFunctions) != 0 {
+		t.Errorf("expected the Lambdas not to be scanned with a malformed pattern")
+	}
+
+}
+
+func TestRunProtectedVersions(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	summary, err := New(svc, Options{
+		Region:            "us-east-1",
+		Retain:            1,
+		ProtectedVersions: []string{"arn:aws:lambda:us-east-1:000000000000:function:func1:1", "func2:1"},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Deleted != 1 || len(svc.Versions("func1")) != 2 || len(svc.Versions("func2")) != 2 {
+		t.Errorf("expected version 1 of func1 and func2 to be left untouched but received %+v", summary)
+	}
+
+	_, err = New(svc, Options{
+		Region:            "us-east-1",
+		ProtectedVersions: []string{"arn:aws:lambda:us-east-1:000000000000:function:func1"},
+	}).Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error to be returned for an unqualified ARN but received %v", err)
+	}
+
+}
+
+func TestRunEdgeReplicas(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	err := svc.AddEdgeReplica("func1", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	summary, err := New(svc, Options{
+		Region: "us-east-1",
+		Retain: 1,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected an edge replica not to be reported as a failure but received %v", err)
+	}
+
+	if summary.Deleted != 2 || summary.EdgeReplicas != 1 || summary.Failed != 0 {
+		t.Errorf("expected 2 versions to be deleted and 1 edge replica to be skipped but received %+v", summary)
+	}
+
+	if got := len(svc.Versions("func1")); got != 2 {
+		t.Errorf("expected 2 versions of func1 to remain but received %d", got)
+	}
+
+}
+
+func TestRunProtectIntegrations(t *testing.T) {
+
+	svc := newInMemoryLambda()
+
+	for _, err := range []error{
+		svc.AddPolicy("func1", "1", `{"Version":"2012-10-17","Statement":[{"Sid":"s3","Effect":"Allow","Principal":{"Service":"s3.amazonaws.com"},"Action":"lambda:InvokeFunction"}]}`),
+		svc.AddEventInvokeConfig("func2", "1"),
+		svc.AddPolicy("func3", "", `{"Version":"2012-10-17","Statement":[]}`),
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	summary, err := New(svc, Options{
+		Region:              "us-east-1",
+		Retain:              1,
+		ProtectIntegrations: true,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Deleted != 1 || summary.Integrations != 2 {
+		t.Errorf("expected 1 version to be deleted and 2 versions to be protected but received %+v", summary)
+	}
+
+	if len(svc.Versions("func1")) != 2 || len(svc.Versions("func2")) != 2 || len(svc.Versions("func3")) != 1 {
+		t.Errorf("expected only the version of func3 to be deleted")
+	}
+
+	svc.AddFunction("func4", 300, 2)
+	svc.Fail(cleanertest.OperationGetPolicy, errors.New("AccessDeniedException"))
+
+	summary, err = New(svc, Options{
+		Region:              "us-east-1",
+		Retain:              1,
+		Functions:           []string{"func4"},
+		ProtectIntegrations: true,
+	}).Run(context.Background())
+	if err == nil || summary.Failed != 1 || summary.Deleted != 0 {
+		t.Errorf("expected a failed policy check to fail the Lambda without deleting any version but received %+v %v", summary, err)
+	}
+
+}
+
+func TestRunTagOverrides(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.AddFunction("func4", 300, 3)
+
+	for _, err := range []error{
+		svc.SetTags("func1", map[string]string{"glc:skip": "true"}),
+		svc.SetTags("func2", map[string]string{"glc:retain": "2"}),
+		svc.SetTags("func3", map[string]string{"glc:retain": "ten"}),
+		svc.SetTags("func4", map[string]string{"glc:retain": "3", "team": "payments"}),
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	retain := int8(1)
+
+	summary, err := New(svc, Options{
+		Region:       "us-east-1",
+		Retain:       1,
+		TagOverrides: true,
+		Overrides:    map[string]Override{"func4": {Retain: &retain}},
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 2 || summary.Deleted != 2 {
+		t.Errorf("expected 2 Lambdas to be cleaned and 2 versions to be deleted but received %+v", summary)
+	}
+
+	if len(svc.Versions("func1")) != 2 || len(svc.Versions("func2")) != 2 || len(svc.Versions("func3")) != 2 || len(svc.Versions("func4")) != 1 {
+		t.Errorf("expected only the versions of func4 to be deleted as the custom list takes precedence over its tags")
+	}
+
+	svc.Fail(cleanertest.OperationListTags, errors.New("AccessDeniedException"))
+
+	_, err = New(svc, Options{
+		Region:       "us-east-1",
+		Retain:       1,
+		TagOverrides: true,
+	}).Run(context.Background())
+	if err == nil {
+		t.Errorf("expected an error to be returned when the tags cannot be read but received %v", err)
+	}
+
+	// The tags are read within the rate limit of the worker pool, so a cancelled clean-up stops before reading them.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := svc.Calls(cleanertest.OperationListTags)
+
+	lambdaList, err := getAllLambdas(context.Background(), svc, nil)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	_, _, err = applyTagOverrides(ctx, svc, newWorkerPool(1, 10), lambdaList, nil)
+	if !errors.Is(err, context.Canceled) || svc.Calls(cleanertest.OperationListTags) != calls {
+		t.Errorf("expected the tags not to be read once the clean-up is cancelled but received %v", err)
+	}
+
+}
+
+func TestRunRetainBehindAlias(t *testing.T) {
+
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 8)
+
+	err := svc.AddAlias("func1", "prod", "5")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	summary, err := New(svc, Options{
+		Region:            "us-east-1",
+		Retain:            1,
+		SkipAliases:       true,
+		RetainBehindAlias: 2,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"3", "4", "5", "8"}
+	if got := svc.Versions("func1"); !slices.Equal(got, want) || summary.Deleted != 4 {
+		t.Errorf("expected the versions %v to be retained but received %v %+v", want, got, summary)
+	}
+
+	if calls := svc.Calls(cleanertest.OperationListAliases); calls != 1 {
+		t.Errorf("expected the aliases to be listed once but received %d calls", calls)
+	}
+
+	// Without --skip-aliases, the version the alias points to is retained and the storage includes the retained versions.
+	svc = cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 10)
+
+	err = svc.AddAlias("func1", "prod", "5")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	summary, err = New(svc, Options{
+		Region:            "us-east-1",
+		Retain:            1,
+		RetainBehindAlias: 2,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want = []string{"3", "4", "5", "10"}
+	if got := svc.Versions("func1"); !slices.Equal(got, want) || summary.Deleted != 6 || summary.Failed != 0 {
+		t.Errorf("expected the versions %v to be retained but received %v %+v", want, got, summary)
+	}
+
+	if summary.Storage != 1100 {
+		t.Errorf("expected the storage of the 10 versions and $LATEST to be reported but received %d", summary.Storage)
+	}
+
+}
+
+func TestRunPruneAliases(t *testing.T) {
+
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 5)
+
+	for _, err := range []error{
+		svc.AddAlias("func1", "live", "5"),
+		svc.AddAlias("func1", "pr-13", "4"),
+		svc.AddAlias("func1", "pr-12", "2"),
+		svc.SetLastModified("func1", "2", time.Now().Add(-30*24*time.Hour)),
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	opts := Options{
+		Region:         "us-east-1",
+		Retain:         1,
+		DryRun:         true,
+		SkipAliases:    true,
+		PruneAliases:   []string{"pr-*"},
+		AliasOlderThan: 14 * 24 * time.Hour,
+	}
+
+	summary, err := New(svc, opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.AliasesPruned != 1 || summary.Planned != 2 || svc.Calls(cleanertest.OperationDeleteAlias) != 0 {
+		t.Errorf("expected 1 alias to be pruned and 2 versions to be planned without deleting any alias but received %+v", summary)
+	}
+
+	opts.DryRun = false
+
+	summary, err = New(svc, opts).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	want := []string{"3", "4", "5"}
+	if got := svc.Versions("func1"); !slices.Equal(got, want) || summary.AliasesPruned != 1 || summary.Deleted != 2 {
+		t.Errorf("expected the versions %v to be retained after pruning pr-12 but received %v %+v", want, got, summary)
+	}
+
+	_, err = New(svc, Options{Region: "us-east-1", PruneAliases: []string{"pr-["}}).Run(context.Background())
+	if err == nil {
+		t.Errorf("expected an error to be returned for an invalid pattern but received %v", err)
+	}
+
+	svc.Fail(cleanertest.OperationDeleteAlias, errors.New("AccessDeniedException"))
+
+	summary, err = New(svc, Options{Region: "us-east-1", Retain: 1, PruneAliases: []string{"pr-*"}}).Run(context.Background())
+	if err == nil || summary.Failed != 1 || summary.Deleted != 0 {
+		t.Errorf("expected a failed alias deletion to fail the Lambda without deleting any version but received %+v %v", summary, err)
+	}
+
+}
+
+func TestRunSkipUpdating(t *testing.T) {
+
+	svc := newInMemoryLambda()
+	svc.AddFunction("func4", 300, 2)
+
+	for _, err := range []error{
+		svc.SetState("func1", types.StatePending, types.LastUpdateStatusInProgress),
+		svc.SetState("func2", types.StateActive, types.LastUpdateStatusInProgress),
+		svc.SetState("func3", types.StateInactive, types.LastUpdateStatusSuccessful),
+		svc.SetLastModified("func1", "$LATEST", time.Now().Add(-48*time.Hour)),
+		svc.SetLastModified("func2", "$LATEST", time.Now().Add(-48*time.Hour)),
+		svc.SetLastModified("func3", "$LATEST", time.Now().Add(-48*time.Hour)),
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	summary, err := New(svc, Options{
+		Region:            "us-east-1",
+		Retain:            1,
+		SkipUpdating:      true,
+		UpdateGracePeriod: time.Hour,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Updating != 3 || summary.Deleted != 1 {
+		t.Errorf("expected 3 Lambdas to be skipped and 1 version to be deleted but received %+v", summary)
+	}
+
+	if len(svc.Versions("func3")) != 1 || len(svc.Versions("func4")) != 2 {
+		t.Errorf("expected only the version of the inactive func3 to be deleted as func4 was modified within the grace period")
+	}
+
+	if svc.Calls(cleanertest.OperationGetFunction) != 4 {
+		t.Errorf("expected the state of each Lambda to be retrieved but received %d calls", svc.Calls(cleanertest.OperationGetFunction))
+	}
+
+}
+
+func TestRunResumeDeletedVersion(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 3)
+
+	state, err := newCheckpoint(path)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	// The previous execution deleted version 2 but stopped before recording the deletion.
+	_, deleteErr := svc.DeleteFunction(context.Background(), &lambda.DeleteFunctionInput{FunctionName: aws.String("func1"), Qualifier: aws.String("2")})
+
+	for _, err := range []error{
+		state.start("us-east-1", []string{"func1"}),
+		state.planned("func1", 400, []types.FunctionConfiguration{
+			{Version: aws.String("1"), CodeSize: 100},
+			{Version: aws.String("2"), CodeSize: 100},
+		}),
+		state.close(),
+		deleteErr,
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	summary, err := New(svc, Options{
+		Region:     "us-east-1",
+		Retain:     1,
+		ResumeFile: path,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Failed != 0 || summary.Deleted != 2 {
+		t.Errorf("expected the version deleted by the previous execution to be counted as deleted but received %+v", summary)
+	}
+
+	if got := svc.Versions("func1"); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("expected only version 3 to remain but received %v", got)
+	}
+
+}
+
+func TestRunResumeStorage(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 3)
+	svc.Fail(cleanertest.OperationDeleteFunction, errors.New("access denied"))
+
+	// The first deletion fails, so the previous execution deletes one of the two planned versions.
+	_, err := New(svc, Options{
+		Region:      "us-east-1",
+		Retain:      1,
+		Concurrency: 1,
+		StateFile:   path,
+	}).Run(context.Background())
+	if err == nil {
+		t.Fatalf("expected an error to be returned but received %v", err)
+	}
+
+	summary, err := New(svc, Options{
+		Region:     "us-east-1",
+		Retain:     1,
+		ResumeFile: path,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	// $LATEST and versions 1 to 3 stored 400 bytes, and the previous execution freed 100 bytes.
+	if summary.Storage != 300 || summary.Freed != 100 {
+		t.Errorf("expected a storage of 300 and 100 freed but received %+v", summary)
+	}
+
+	if got := svc.Versions("func1"); !slices.Equal(got, []string{"3"}) {
+		t.Errorf("expected only version 3 to remain but received %v", got)
+	}
+
+}
+
+func TestRunResumeTagOverrides(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 100, 6)
+	svc.AddFunction("func2", 100, 3)
+
+	state, err := newCheckpoint(path)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	// The previous execution stopped before planning either Lambda.
+	for _, err := range []error{
+		state.start("us-east-1", []string{"func1", "func2"}),
+		state.close(),
+		svc.SetTags("func1", map[string]string{"glc:retain": "4"}),
+		svc.SetTags("func2", map[string]string{"glc:skip": "true"}),
+	} {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	summary, err := New(svc, Options{
+		Region:       "us-east-1",
+		Retain:       1,
+		TagOverrides: true,
+		ResumeFile:   path,
+	}).Run(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if summary.Lambdas != 1 || summary.Deleted != 2 {
+		t.Errorf("expected 1 Lambda to be cleaned and 2 versions to be deleted but received %+v", summary)
+	}
+
+	if got := svc.Versions("func1"); !slices.Equal(got, []string{"3", "4", "5", "6"}) {
+		t.Errorf("expected the 4 versions retained by the tag to remain but received %v", got)
+	}
+
+	if got := svc.Versions("func2"); len(got) != 3 {
+		t.Errorf("expected the versions of the skipped Lambda to remain but received %v", got)
+	}
+
+}
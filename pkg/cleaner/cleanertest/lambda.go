@@ -0,0 +1,809 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+/*
+Package cleanertest provides an in-memory implementation of the AWS Lambda operations used by the cleaner package.
+
+The Lambda type stores functions, versions, and aliases in memory and paginates its results the same way the AWS Lambda API does.
+Errors and throttling can be injected for any operation so that failure paths can be tested without an AWS account or a localstack container.
+
+	svc := cleanertest.NewLambda()
+	svc.AddFunction("func1", 1024, 3)
+	svc.AddAlias("func1", "live", "2")
+	svc.Throttle(cleanertest.OperationDeleteFunction, 1)
+
+	summary, err := cleaner.New(svc, cleaner.Options{Region: svc.Region}).Run(ctx)
+*/
+package cleanertest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	// DefaultRegion is the region used in the ARNs of a Lambda returned by NewLambda.
+	DefaultRegion string = "us-east-1"
+	// DefaultAccountID is the account ID used in the ARNs of a Lambda returned by NewLambda.
+	DefaultAccountID string = "000000000000"
+	// DefaultPageSize is the maximum number of items returned in a single page. It matches the limit of the AWS Lambda API.
+	DefaultPageSize int32 = 50
+	// latestVersion is the version name of the unpublished version of a function.
+	latestVersion string = "$LATEST"
+	// lastModifiedLayout is the format of the LastModified value returned by the AWS Lambda API.
+	lastModifiedLayout string = "2006-01-02T15:04:05.000-0700"
+)
+
+// The names of the operations that accept injected errors and throttling.
+const (
+	OperationListFunctions                  string = "ListFunctions"
+	OperationListVersionsByFunction         string = "ListVersionsByFunction"
+	OperationListAliases                    string = "ListAliases"
+	OperationGetFunction                    string = "GetFunction"
+	OperationDeleteFunction                 string = "DeleteFunction"
+	OperationGetPolicy                      string = "GetPolicy"
+	OperationListFunctionEventInvokeConfigs string = "ListFunctionEventInvokeConfigs"
+	OperationListTags                       string = "ListTags"
+	OperationDeleteAlias                    string = "DeleteAlias"
+	OperationCreateAlias                    string = "CreateAlias"
+)
+
+// Lambda is an in-memory implementation of the AWS Lambda API operations used by the cleaner package.
+// It is safe for concurrent use.
+type Lambda struct {
+	// Region is the region used in the function ARNs.
+	Region string
+	// AccountID is the account ID used in the function ARNs.
+	AccountID string
+	// PageSize is the maximum number of items returned in a single page, regardless of the MaxItems requested.
+	PageSize int32
+
+	mu        sync.Mutex
+	functions map[string]*function
+	faults    map[string][]error
+	throttles map[string]int
+	calls     map[string]int
+}
+
+// function is a Lambda function stored in memory.
+type function struct {
+	latest      types.FunctionConfiguration
+	versions    []types.FunctionConfiguration
+	aliases     []types.AliasConfiguration
+	replicated  map[string]bool
+	policies    map[string]string
+	invokes     []types.FunctionEventInvokeConfig
+	tags        map[string]string
+	nextVersion int
+}
+
+// NewLambda returns an empty Lambda that uses the default region, account ID, and page size.
+func NewLambda() *Lambda {
+	return &Lambda{
+		Region:    DefaultRegion,
+		AccountID: DefaultAccountID,
+		PageSize:  DefaultPageSize,
+		functions: make(map[string]*function),
+		faults:    make(map[string][]error),
+		throttles: make(map[string]int),
+		calls:     make(map[string]int),
+	}
+}
+
+// AddFunction creates a function with the provided code size and publishes the number of versions requested.
+// If the function already exists, the versions are published to the existing function.
+func (l *Lambda) AddFunction(name string, codeSize int64, versions int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		fn = &function{
+			latest: types.FunctionConfiguration{
+				FunctionName:     aws.String(name),
+				FunctionArn:      aws.String(l.functionArn(name)),
+				Description:      aws.String(name),
+				Version:          aws.String(latestVersion),
+				LastModified:     aws.String(time.Now().UTC().Format(lastModifiedLayout)),
+				CodeSize:         codeSize,
+				Runtime:          types.RuntimeNodejs18x,
+				State:            types.StateActive,
+				LastUpdateStatus: types.LastUpdateStatusSuccessful,
+			},
+			nextVersion: 1,
+		}
+		l.functions[name] = fn
+	}
+
+	for range versions {
+		l.publish(fn, codeSize)
+	}
+}
+
+// PublishVersion publishes a new version of an existing function with the provided code size and returns the version number.
+func (l *Lambda) PublishVersion(name string, codeSize int64) (string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return "", notFound(name)
+	}
+
+	return l.publish(fn, codeSize), nil
+}
+
+// AddAlias creates an alias that points to an existing version of a function.
+func (l *Lambda) AddAlias(name, alias, version string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, err := l.addAlias(name, alias, version)
+
+	return err
+}
+
+// addAlias creates an alias of a function and returns its configuration. The caller must hold the lock.
+func (l *Lambda) addAlias(name, alias, version string) (types.AliasConfiguration, error) {
+	fn, ok := l.functions[name]
+	if !ok {
+		return types.AliasConfiguration{}, notFound(name)
+	}
+
+	if version != latestVersion && fn.version(version) < 0 {
+		return types.AliasConfiguration{}, notFound(name + ":" + version)
+	}
+
+	config := types.AliasConfiguration{
+		Name:            aws.String(alias),
+		AliasArn:        aws.String(l.functionArn(name) + ":" + alias),
+		FunctionVersion: aws.String(version),
+	}
+	fn.aliases = append(fn.aliases, config)
+
+	return config, nil
+}
+
+// SetLastModified changes the LastModified value of a published version of a function, or of the function itself when the version is $LATEST.
+func (l *Lambda) SetLastModified(name, version string, lastModified time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	if version == latestVersion {
+		fn.latest.LastModified = aws.String(lastModified.UTC().Format(lastModifiedLayout))
+
+		return nil
+	}
+
+	index := fn.version(version)
+	if index < 0 {
+		return notFound(name + ":" + version)
+	}
+
+	fn.versions[index].LastModified = aws.String(lastModified.UTC().Format(lastModifiedLayout))
+
+	return nil
+}
+
+// AddEdgeReplica marks a published version of a function as replicated by Lambda@Edge. The version cannot be deleted, which matches the behavior of the AWS Lambda API while the replicas exist.
+func (l *Lambda) AddEdgeReplica(name, version string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	if fn.version(version) < 0 {
+		return notFound(name + ":" + version)
+	}
+
+	if fn.replicated == nil {
+		fn.replicated = make(map[string]bool)
+	}
+
+	fn.replicated[version] = true
+
+	return nil
+}
+
+// AddPolicy sets the resource-based policy of a version or an alias of a function. An empty qualifier sets the policy of the unqualified function.
+func (l *Lambda) AddPolicy(name, qualifier, policy string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	if fn.policies == nil {
+		fn.policies = make(map[string]string)
+	}
+
+	fn.policies[qualifier] = policy
+
+	return nil
+}
+
+// AddEventInvokeConfig creates an event invoke configuration for a version or an alias of a function.
+func (l *Lambda) AddEventInvokeConfig(name, qualifier string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	fn.invokes = append(fn.invokes, types.FunctionEventInvokeConfig{
+		FunctionArn:          aws.String(l.functionArn(name) + ":" + qualifier),
+		MaximumRetryAttempts: aws.Int32(2),
+	})
+
+	return nil
+}
+
+// SetState changes the state and the status of the last update of a function, such as a function that is being deployed.
+func (l *Lambda) SetState(name string, state types.State, status types.LastUpdateStatus) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	fn.latest.State = state
+	fn.latest.LastUpdateStatus = status
+
+	return nil
+}
+
+// SetTags replaces the tags of a function.
+func (l *Lambda) SetTags(name string, tags map[string]string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return notFound(name)
+	}
+
+	fn.tags = tags
+
+	return nil
+}
+
+// Versions returns the published versions of a function, excluding $LATEST, in the order they were published.
+func (l *Lambda) Versions(name string) []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return nil
+	}
+
+	versions := make([]string, 0, len(fn.versions))
+	for _, v := range fn.versions {
+		versions = append(versions, *v.Version)
+	}
+
+	return versions
+}
+
+// Fail queues errors that are returned, one per call, by the next calls to the operation.
+func (l *Lambda) Fail(operation string, errs ...error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.faults[operation] = append(l.faults[operation], errs...)
+}
+
+// Throttle makes the next n calls to the operation fail with a TooManyRequestsException.
+// Throttling takes precedence over the errors queued with Fail.
+func (l *Lambda) Throttle(operation string, n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.throttles[operation] = l.throttles[operation] + n
+}
+
+// Calls returns the number of calls made to the operation, including the calls that failed.
+func (l *Lambda) Calls(operation string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.calls[operation]
+}
+
+// ListFunctions returns the $LATEST configuration of the functions sorted by name.
+// The state fields are omitted, which matches the AWS Lambda API that only returns them from GetFunction.
+func (l *Lambda) ListFunctions(_ context.Context, params *lambda.ListFunctionsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionsOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationListFunctions)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(l.functions))
+	for name := range l.functions {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	configs := make([]types.FunctionConfiguration, 0, len(names))
+	for _, name := range names {
+		config := l.functions[name].latest
+		config.State = ""
+		config.LastUpdateStatus = ""
+
+		configs = append(configs, config)
+	}
+
+	page, next, err := paginate(configs, params.Marker, params.MaxItems, l.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListFunctionsOutput{
+		Functions:  page,
+		NextMarker: next,
+	}, nil
+}
+
+// ListVersionsByFunction returns $LATEST followed by the published versions of a function.
+func (l *Lambda) ListVersionsByFunction(_ context.Context, params *lambda.ListVersionsByFunctionInput, _ ...func(*lambda.Options)) (*lambda.ListVersionsByFunctionOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationListVersionsByFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, _, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	configs := append([]types.FunctionConfiguration{fn.latest}, fn.versions...)
+
+	page, next, err := paginate(configs, params.Marker, params.MaxItems, l.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListVersionsByFunctionOutput{
+		Versions:   page,
+		NextMarker: next,
+	}, nil
+}
+
+// ListAliases returns the aliases of a function. The aliases are filtered by version if FunctionVersion is provided.
+func (l *Lambda) ListAliases(_ context.Context, params *lambda.ListAliasesInput, _ ...func(*lambda.Options)) (*lambda.ListAliasesOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationListAliases)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, _, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	var aliases []types.AliasConfiguration
+
+	for _, alias := range fn.aliases {
+		if params.FunctionVersion == nil || *params.FunctionVersion == *alias.FunctionVersion {
+			aliases = append(aliases, alias)
+		}
+	}
+
+	page, next, err := paginate(aliases, params.Marker, params.MaxItems, l.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListAliasesOutput{
+		Aliases:    page,
+		NextMarker: next,
+	}, nil
+}
+
+// CreateAlias creates an alias that points to an existing version of a function.
+func (l *Lambda) CreateAlias(_ context.Context, params *lambda.CreateAliasInput, _ ...func(*lambda.Options)) (*lambda.CreateAliasOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationCreateAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := l.addAlias(aws.ToString(params.FunctionName), aws.ToString(params.Name), aws.ToString(params.FunctionVersion))
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.CreateAliasOutput{
+		Name:            config.Name,
+		AliasArn:        config.AliasArn,
+		FunctionVersion: config.FunctionVersion,
+		Description:     params.Description,
+	}, nil
+}
+
+// DeleteAlias deletes an alias of a function. The versions the alias points to are not changed.
+func (l *Lambda) DeleteAlias(_ context.Context, params *lambda.DeleteAliasInput, _ ...func(*lambda.Options)) (*lambda.DeleteAliasOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationDeleteAlias)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, _, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	index := slices.IndexFunc(fn.aliases, func(alias types.AliasConfiguration) bool {
+		return aws.ToString(alias.Name) == aws.ToString(params.Name)
+	})
+	if index < 0 {
+		return nil, notFound(aws.ToString(params.FunctionName) + ":" + aws.ToString(params.Name))
+	}
+
+	fn.aliases = slices.Delete(fn.aliases, index, index+1)
+
+	return &lambda.DeleteAliasOutput{}, nil
+}
+
+// GetFunction returns the configuration of a function. The version or alias is taken from the Qualifier or the qualified ARN.
+func (l *Lambda) GetFunction(_ context.Context, params *lambda.GetFunctionInput, _ ...func(*lambda.Options)) (*lambda.GetFunctionOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationGetFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, qualifier, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Qualifier != nil {
+		qualifier = *params.Qualifier
+	}
+
+	config, err := fn.resolve(qualifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.GetFunctionOutput{
+		Configuration: &config,
+		Tags:          fn.tags,
+	}, nil
+}
+
+// ListTags returns the tags of a function. The function is identified by its ARN, which matches the behavior of the AWS Lambda API.
+func (l *Lambda) ListTags(_ context.Context, params *lambda.ListTagsInput, _ ...func(*lambda.Options)) (*lambda.ListTagsOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationListTags)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := aws.ToString(params.Resource)
+	if !strings.HasPrefix(resource, "arn:") {
+		return nil, &types.InvalidParameterValueException{
+			Message: aws.String("The resource ARN is not valid: " + resource),
+		}
+	}
+
+	fn, _, err := l.function(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListTagsOutput{
+		Tags: fn.tags,
+	}, nil
+}
+
+// GetPolicy returns the resource-based policy of a function, or of the version or alias identified by the Qualifier or the qualified ARN.
+// A ResourceNotFoundException is returned if no policy is set, which matches the behavior of the AWS Lambda API.
+func (l *Lambda) GetPolicy(_ context.Context, params *lambda.GetPolicyInput, _ ...func(*lambda.Options)) (*lambda.GetPolicyOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationGetPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, qualifier, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Qualifier != nil {
+		qualifier = *params.Qualifier
+	}
+
+	policy, ok := fn.policies[qualifier]
+	if !ok {
+		return nil, &types.ResourceNotFoundException{
+			Message: aws.String("The resource you requested does not exist."),
+		}
+	}
+
+	return &lambda.GetPolicyOutput{
+		Policy: aws.String(policy),
+	}, nil
+}
+
+// ListFunctionEventInvokeConfigs returns the event invoke configurations of the versions and aliases of a function.
+func (l *Lambda) ListFunctionEventInvokeConfigs(_ context.Context, params *lambda.ListFunctionEventInvokeConfigsInput, _ ...func(*lambda.Options)) (*lambda.ListFunctionEventInvokeConfigsOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationListFunctionEventInvokeConfigs)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, _, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	page, next, err := paginate(fn.invokes, params.Marker, params.MaxItems, l.PageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &lambda.ListFunctionEventInvokeConfigsOutput{
+		FunctionEventInvokeConfigs: page,
+		NextMarker:                 next,
+	}, nil
+}
+
+// DeleteFunction deletes a published version of a function, or the function and all its versions if no version is provided.
+// A version referenced by an alias or replicated by Lambda@Edge cannot be deleted, which matches the behavior of the AWS Lambda API.
+func (l *Lambda) DeleteFunction(_ context.Context, params *lambda.DeleteFunctionInput, _ ...func(*lambda.Options)) (*lambda.DeleteFunctionOutput, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	err := l.call(OperationDeleteFunction)
+	if err != nil {
+		return nil, err
+	}
+
+	fn, qualifier, err := l.function(aws.ToString(params.FunctionName))
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Qualifier != nil {
+		qualifier = *params.Qualifier
+	}
+
+	if qualifier == "" {
+		delete(l.functions, *fn.latest.FunctionName)
+
+		return &lambda.DeleteFunctionOutput{}, nil
+	}
+
+	if qualifier == latestVersion {
+		return nil, &types.InvalidParameterValueException{
+			Message: aws.String("$LATEST version cannot be deleted without deleting the function."),
+		}
+	}
+
+	index := fn.version(qualifier)
+	if index < 0 {
+		return nil, notFound(*fn.latest.FunctionName + ":" + qualifier)
+	}
+
+	if fn.replicated[qualifier] {
+		return nil, &types.InvalidParameterValueException{
+			Message: aws.String(fmt.Sprintf("Lambda was unable to delete %s:%s because it is a replicated function. Please see our documentation for Deleting Lambda@Edge Functions and Replicas.", *fn.latest.FunctionArn, qualifier)),
+		}
+	}
+
+	var referenced []string
+
+	for _, alias := range fn.aliases {
+		if *alias.FunctionVersion == qualifier {
+			referenced = append(referenced, *alias.Name)
+		}
+	}
+
+	if len(referenced) > 0 {
+		return nil, &types.ResourceConflictException{
+			Message: aws.String(fmt.Sprintf("Unable to delete version because the following aliases reference it: %v", referenced)),
+		}
+	}
+
+	fn.versions = slices.Delete(fn.versions, index, index+1)
+
+	return &lambda.DeleteFunctionOutput{}, nil
+}
+
+// call records a call to the operation and returns the injected error, if any.
+func (l *Lambda) call(operation string) error {
+	l.calls[operation]++
+
+	if l.throttles[operation] > 0 {
+		l.throttles[operation]--
+
+		return &types.TooManyRequestsException{
+			Message: aws.String("Rate exceeded"),
+			Reason:  types.ThrottleReasonCallerRateLimitExceeded,
+		}
+	}
+
+	if len(l.faults[operation]) > 0 {
+		err := l.faults[operation][0]
+		l.faults[operation] = l.faults[operation][1:]
+
+		return err
+	}
+
+	return nil
+}
+
+// function returns the function identified by a name, a partial ARN, or an ARN, along with the qualifier of a qualified identifier.
+func (l *Lambda) function(id string) (*function, string, error) {
+	name, qualifier := id, ""
+
+	parts := strings.Split(id, ":")
+
+	switch {
+	case strings.HasPrefix(id, "arn:") && len(parts) >= 7:
+		name = parts[6]
+		if len(parts) > 7 {
+			qualifier = parts[7]
+		}
+	case len(parts) >= 3 && parts[1] == "function":
+		name = parts[2]
+		if len(parts) > 3 {
+			qualifier = parts[3]
+		}
+	case len(parts) == 2:
+		name, qualifier = parts[0], parts[1]
+	}
+
+	fn, ok := l.functions[name]
+	if !ok {
+		return nil, "", notFound(id)
+	}
+
+	return fn, qualifier, nil
+}
+
+// publish adds a new version to the function and returns its version number.
+func (l *Lambda) publish(fn *function, codeSize int64) string {
+	version := strconv.Itoa(fn.nextVersion)
+	fn.nextVersion++
+
+	fn.latest.CodeSize = codeSize
+	fn.latest.LastModified = aws.String(time.Now().UTC().Format(lastModifiedLayout))
+
+	config := fn.latest
+	config.Version = aws.String(version)
+	config.FunctionArn = aws.String(*fn.latest.FunctionArn + ":" + version)
+	fn.versions = append(fn.versions, config)
+
+	return version
+}
+
+// functionArn returns the unqualified ARN of a function.
+func (l *Lambda) functionArn(name string) string {
+	return fmt.Sprintf("arn:aws:lambda:%s:%s:function:%s", l.Region, l.AccountID, name)
+}
+
+// version returns the index of a published version, or -1 if the version does not exist.
+func (fn *function) version(version string) int {
+	return slices.IndexFunc(fn.versions, func(v types.FunctionConfiguration) bool {
+		return *v.Version == version
+	})
+}
+
+// resolve returns the configuration of the version or alias identified by the qualifier.
+func (fn *function) resolve(qualifier string) (types.FunctionConfiguration, error) {
+	if qualifier == "" || qualifier == latestVersion {
+		return fn.latest, nil
+	}
+
+	for _, alias := range fn.aliases {
+		if *alias.Name == qualifier {
+			qualifier = *alias.FunctionVersion
+
+			break
+		}
+	}
+
+	if qualifier == latestVersion {
+		return fn.latest, nil
+	}
+
+	index := fn.version(qualifier)
+	if index < 0 {
+		return types.FunctionConfiguration{}, notFound(*fn.latest.FunctionName + ":" + qualifier)
+	}
+
+	return fn.versions[index], nil
+}
+
+// paginate returns the page of items that starts at the marker and the marker of the next page.
+func paginate[T any](items []T, marker *string, maxItems *int32, pageSize int32) ([]T, *string, error) {
+	start := 0
+
+	if marker != nil {
+		var err error
+
+		start, err = strconv.Atoi(*marker)
+		if err != nil || start < 0 || start > len(items) {
+			return nil, nil, &types.InvalidParameterValueException{
+				Message: aws.String("Invalid marker " + *marker),
+			}
+		}
+	}
+
+	size := int(pageSize)
+	if maxItems != nil && *maxItems > 0 && (size < 1 || int(*maxItems) < size) {
+		size = int(*maxItems)
+	}
+
+	if size < 1 {
+		size = int(DefaultPageSize)
+	}
+
+	end := min(start+size, len(items))
+
+	var next *string
+	if end < len(items) {
+		next = aws.String(strconv.Itoa(end))
+	}
+
+	return items[start:end], next, nil
+}
+
+// notFound returns the error of the AWS Lambda API for a missing function or version.
+func notFound(id string) error {
+	return &types.ResourceNotFoundException{
+		Message: aws.String("Function not found: " + id),
+	}
+}
@@ -0,0 +1,440 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleanertest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestListFunctionsPagination(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.PageSize = 2
+
+	svc.AddFunction("func3", 100, 1)
+	svc.AddFunction("func1", 100, 1)
+	svc.AddFunction("func2", 100, 1)
+
+	var names []string
+
+	p := lambda.NewListFunctionsPaginator(svc, &lambda.ListFunctionsInput{
+		MaxItems: aws.Int32(10000),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+
+		if len(page.Functions) > 2 {
+			t.Errorf("expected at most 2 functions per page but received %d", len(page.Functions))
+		}
+
+		for _, fn := range page.Functions {
+			names = append(names, *fn.FunctionName)
+		}
+	}
+
+	if len(names) != 3 || names[0] != "func1" || names[2] != "func3" {
+		t.Errorf("expected the functions to be listed in order but received %v", names)
+	}
+
+	if svc.Calls(OperationListFunctions) != 2 {
+		t.Errorf("expected 2 calls to ListFunctions but received %d", svc.Calls(OperationListFunctions))
+	}
+
+}
+
+func TestListVersionsByFunction(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.PageSize = 1
+
+	svc.AddFunction("func1", 100, 2)
+
+	var versions []string
+
+	p := lambda.NewListVersionsByFunctionPaginator(svc, &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1"),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+
+		for _, v := range page.Versions {
+			versions = append(versions, *v.Version)
+		}
+	}
+
+	if len(versions) != 3 || versions[0] != "$LATEST" || versions[2] != "2" {
+		t.Errorf("expected $LATEST followed by the published versions but received %v", versions)
+	}
+
+	_, err := svc.ListVersionsByFunction(ctx, &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String("func22"),
+	})
+
+	var rnf *types.ResourceNotFoundException
+	if !errors.As(err, &rnf) {
+		t.Errorf("expected a ResourceNotFoundException but received %v", err)
+	}
+
+}
+
+func TestListAliases(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+
+	svc.AddFunction("func1", 100, 3)
+
+	err := svc.AddAlias("func1", "live", "2")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	created, err := svc.CreateAlias(ctx, &lambda.CreateAliasInput{
+		FunctionName:    aws.String("func1"),
+		Name:            aws.String("dev"),
+		FunctionVersion: aws.String("3"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if aws.ToString(created.AliasArn) != "arn:aws:lambda:us-east-1:000000000000:function:func1:dev" {
+		t.Errorf("expected the ARN of the dev alias to be returned but received %s", aws.ToString(created.AliasArn))
+	}
+
+	err = svc.AddAlias("func1", "old", "7")
+	if err == nil {
+		t.Errorf("expected an error to be returned for a missing version but received %v", err)
+	}
+
+	output, err := svc.ListAliases(ctx, &lambda.ListAliasesInput{
+		FunctionName: aws.String("func1"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(output.Aliases) != 2 {
+		t.Errorf("expected 2 aliases to be returned but received %d", len(output.Aliases))
+	}
+
+	output, err = svc.ListAliases(ctx, &lambda.ListAliasesInput{
+		FunctionName:    aws.String("func1"),
+		FunctionVersion: aws.String("2"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(output.Aliases) != 1 || *output.Aliases[0].Name != "live" {
+		t.Errorf("expected the live alias to be returned but received %v", output.Aliases)
+	}
+
+}
+
+func TestGetFunction(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+
+	svc.AddFunction("func1", 100, 1)
+
+	_, err := svc.PublishVersion("func1", 200)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	err = svc.AddAlias("func1", "live", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	output, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String("func1"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if *output.Configuration.Version != "$LATEST" || output.Configuration.CodeSize != 200 {
+		t.Errorf("expected the $LATEST version with a code size of 200 but received %s %d", *output.Configuration.Version, output.Configuration.CodeSize)
+	}
+
+	output, err = svc.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String("func1:live"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if *output.Configuration.Version != "1" || output.Configuration.CodeSize != 100 {
+		t.Errorf("expected version 1 with a code size of 100 but received %s %d", *output.Configuration.Version, output.Configuration.CodeSize)
+	}
+
+	_, err = svc.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: aws.String("func22"),
+	})
+
+	var rnf *types.ResourceNotFoundException
+	if !errors.As(err, &rnf) {
+		t.Errorf("expected a ResourceNotFoundException but received %v", err)
+	}
+
+}
+
+func TestGetPolicy(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.AddFunction("func1", 100, 2)
+
+	err := svc.AddPolicy("func1", "2", `{"Statement":[]}`)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	out, err := svc.GetPolicy(ctx, &lambda.GetPolicyInput{FunctionName: aws.String("func1:2")})
+	if err != nil || *out.Policy != `{"Statement":[]}` {
+		t.Errorf("expected the policy of version 2 to be returned but received %v %v", out, err)
+	}
+
+	_, err = svc.GetPolicy(ctx, &lambda.GetPolicyInput{FunctionName: aws.String("func1"), Qualifier: aws.String("1")})
+
+	var rnf *types.ResourceNotFoundException
+	if !errors.As(err, &rnf) {
+		t.Errorf("expected a ResourceNotFoundException for a version without a policy but received %v", err)
+	}
+
+}
+
+func TestDeleteAlias(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.AddFunction("func1", 100, 2)
+
+	err := svc.AddAlias("func1", "pr-1", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	_, err = svc.DeleteAlias(ctx, &lambda.DeleteAliasInput{FunctionName: aws.String("func1"), Name: aws.String("pr-1")})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	out, err := svc.ListAliases(ctx, &lambda.ListAliasesInput{FunctionName: aws.String("func1")})
+	if err != nil || len(out.Aliases) != 0 {
+		t.Errorf("expected the alias to be deleted but received %v %v", out, err)
+	}
+
+	_, err = svc.DeleteAlias(ctx, &lambda.DeleteAliasInput{FunctionName: aws.String("func1"), Name: aws.String("pr-1")})
+
+	var rnf *types.ResourceNotFoundException
+	if !errors.As(err, &rnf) {
+		t.Errorf("expected a ResourceNotFoundException for a missing alias but received %v", err)
+	}
+
+}
+
+func TestSetState(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.AddFunction("func1", 100, 1)
+
+	err := svc.SetState("func1", types.StatePending, types.LastUpdateStatusInProgress)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	out, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{FunctionName: aws.String("func1")})
+	if err != nil || out.Configuration.State != types.StatePending || out.Configuration.LastUpdateStatus != types.LastUpdateStatusInProgress {
+		t.Errorf("expected the state of func1 to be returned but received %v %v", out, err)
+	}
+
+	list, err := svc.ListFunctions(ctx, &lambda.ListFunctionsInput{})
+	if err != nil || list.Functions[0].State != "" || list.Functions[0].LastUpdateStatus != "" {
+		t.Errorf("expected the state fields to be omitted from ListFunctions but received %v %v", list, err)
+	}
+
+}
+
+func TestListTags(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.AddFunction("func1", 100, 2)
+
+	err := svc.SetTags("func1", map[string]string{"glc:retain": "5"})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	out, err := svc.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String("arn:aws:lambda:us-east-1:000000000000:function:func1")})
+	if err != nil || out.Tags["glc:retain"] != "5" {
+		t.Errorf("expected the tags of func1 to be returned but received %v %v", out, err)
+	}
+
+	_, err = svc.ListTags(ctx, &lambda.ListTagsInput{Resource: aws.String("func1")})
+	if err == nil {
+		t.Errorf("expected an error to be returned for a resource that is not an ARN but received %v", err)
+	}
+
+	err = svc.SetTags("func9", nil)
+	if err == nil {
+		t.Errorf("expected an error to be returned for a missing function but received %v", err)
+	}
+
+}
+
+func TestListFunctionEventInvokeConfigs(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+	svc.AddFunction("func1", 100, 2)
+
+	err := svc.AddEventInvokeConfig("func1", "1")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	out, err := svc.ListFunctionEventInvokeConfigs(ctx, &lambda.ListFunctionEventInvokeConfigsInput{FunctionName: aws.String("func1")})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if len(out.FunctionEventInvokeConfigs) != 1 || *out.FunctionEventInvokeConfigs[0].FunctionArn != "arn:aws:lambda:us-east-1:000000000000:function:func1:1" {
+		t.Errorf("expected the configuration of version 1 to be returned but received %v", out.FunctionEventInvokeConfigs)
+	}
+
+	err = svc.AddEventInvokeConfig("func2", "1")
+	if err == nil {
+		t.Errorf("expected an error to be returned for a missing function but received %v", err)
+	}
+
+}
+
+func TestDeleteFunction(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+
+	svc.AddFunction("func1", 100, 3)
+	svc.AddFunction("func2", 100, 1)
+
+	err := svc.AddAlias("func1", "live", "2")
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	_, err = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String("func1"),
+		Qualifier:    aws.String("1"),
+	})
+	if err != nil {
+		t.Errorf("expected no error to be returned but received %v", err)
+	}
+
+	_, err = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String("func1"),
+		Qualifier:    aws.String("2"),
+	})
+
+	var conflict *types.ResourceConflictException
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected a ResourceConflictException for a version referenced by an alias but received %v", err)
+	}
+
+	_, err = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String("func1"),
+		Qualifier:    aws.String("$LATEST"),
+	})
+
+	var invalid *types.InvalidParameterValueException
+	if !errors.As(err, &invalid) {
+		t.Errorf("expected an InvalidParameterValueException for $LATEST but received %v", err)
+	}
+
+	_, err = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String("func1"),
+		Qualifier:    aws.String("1"),
+	})
+
+	var rnf *types.ResourceNotFoundException
+	if !errors.As(err, &rnf) {
+		t.Errorf("expected a ResourceNotFoundException for a deleted version but received %v", err)
+	}
+
+	versions := svc.Versions("func1")
+	if len(versions) != 2 || versions[0] != "2" || versions[1] != "3" {
+		t.Errorf("expected versions 2 and 3 to remain but received %v", versions)
+	}
+
+	_, err = svc.DeleteFunction(ctx, &lambda.DeleteFunctionInput{
+		FunctionName: aws.String("func2"),
+	})
+	if err != nil {
+		t.Errorf("expected no error to be returned but received %v", err)
+	}
+
+	if svc.Versions("func2") != nil {
+		t.Errorf("expected func2 to be deleted but received the versions %v", svc.Versions("func2"))
+	}
+
+}
+
+func TestInjectedErrors(t *testing.T) {
+
+	ctx := context.Background()
+	svc := NewLambda()
+
+	svc.AddFunction("func1", 100, 1)
+
+	injected := errors.New("injected")
+	svc.Fail(OperationListFunctions, injected)
+	svc.Throttle(OperationListFunctions, 2)
+
+	for i := range 2 {
+		_, err := svc.ListFunctions(ctx, &lambda.ListFunctionsInput{})
+
+		var throttled *types.TooManyRequestsException
+		if !errors.As(err, &throttled) {
+			t.Errorf("expected call %d to be throttled but received %v", i+1, err)
+		}
+	}
+
+	_, err := svc.ListFunctions(ctx, &lambda.ListFunctionsInput{})
+	if !errors.Is(err, injected) {
+		t.Errorf("expected the injected error to be returned but received %v", err)
+	}
+
+	output, err := svc.ListFunctions(ctx, &lambda.ListFunctionsInput{})
+	if err != nil {
+		t.Errorf("expected no error to be returned but received %v", err)
+	}
+
+	if len(output.Functions) != 1 {
+		t.Errorf("expected 1 function to be returned but received %d", len(output.Functions))
+	}
+
+	if svc.Calls(OperationListFunctions) != 4 {
+		t.Errorf("expected 4 calls to ListFunctions but received %d", svc.Calls(OperationListFunctions))
+	}
+
+}
@@ -0,0 +1,309 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// functionResult is the outcome of a single Lambda going through the clean-up pipeline.
+// Only the totals are kept so that the versions of a Lambda can be released as soon as the Lambda is processed.
+type functionResult struct {
+	index         int
+	name          string
+	storage       int64
+	planned       int
+	plannedSize   int64
+	deleted       int
+	freed         int64
+	edgeReplicas  int
+	integrations  int
+	aliasesPruned int
+	updating      bool
+	interrupted   bool
+	err           error
+}
+
+// execution is the state of a single call to Run that is derived from the Options. It is kept apart from the Options so that the Options remain a plain input value that can be reused across runs.
+type execution struct {
+	// keepVersions report whether a version is retained. They are compiled from KeepDescription and ProtectedVersions.
+	keepVersions []versionPredicate
+}
+
+// runCleanPipeline streams each Lambda through the list, plan, delete, and report stages.
+// The Lambdas are processed concurrently through the worker pool, which is shared with the scan. The results are reported in the same order as the provided list of Lambdas.
+// The progress of each Lambda is recorded in the checkpoint, which may be nil.
+// An error is returned if one or more Lambdas failed to complete the pipeline.
+func runCleanPipeline(ctx context.Context, svc LambdaAPI, pool *workerPool, lambdaList []types.FunctionConfiguration, opts Options, run execution, state *checkpoint) (Summary, error) {
+	var (
+		summary Summary
+		err     error
+	)
+
+	results := make(chan functionResult, pool.concurrency)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		summary = reportResults(results, opts)
+	}()
+
+	err = pool.run(ctx, len(lambdaList), func(i int) error {
+		result := cleanFunction(ctx, svc, pool, lambdaList[i], opts, run, state)
+		result.index = i
+
+		results <- result
+
+		return nil
+	})
+
+	close(results)
+	<-done
+
+	if err == nil && summary.Failed > 0 {
+		err = fmt.Errorf("%d of %d Lambdas failed to complete the clean-up process", summary.Failed, summary.Lambdas)
+	}
+
+	return summary, err
+}
+
+// cleanFunction executes the list, plan, and delete stages for a single Lambda.
+// Any error is recorded in the returned functionResult so that the remaining Lambdas can continue through the pipeline.
+// If the context is cancelled, the Lambda is marked as interrupted and no new deletions are started.
+// If a previous execution recorded a plan for the Lambda in the checkpoint, the list and plan stages are skipped and the pending versions are deleted.
+func cleanFunction(ctx context.Context, svc LambdaAPI, pool *workerPool, item types.FunctionConfiguration, opts Options, run execution, state *checkpoint) functionResult {
+	var (
+		deleteList [][]types.FunctionConfiguration
+		pruned     []string
+	)
+
+	result := functionResult{
+		name: *item.FunctionName,
+	}
+
+	opts = opts.forFunction(result.name)
+
+	if opts.SkipUpdating || opts.UpdateGracePeriod > 0 {
+		reason, err := updateInProgress(ctx, svc, pool, item, opts, time.Now())
+		if err != nil {
+			if ctx.Err() != nil {
+				result.interrupted = true
+
+				return result
+			}
+
+			result.err = fmt.Errorf("failed to retrieve the state of %s: %w", result.name, err)
+
+			return result
+		}
+
+		// The Lambda is not recorded as completed in the checkpoint so that a resumed clean-up processes it again.
+		if reason != "" {
+			log.Info("Skipping " + result.name + " as it is being deployed. " + reason)
+
+			result.updating = true
+
+			return result
+		}
+	}
+
+	progress, resumed := state.lambdaProgress(result.name)
+	if resumed {
+		if progress.completed {
+			return result
+		}
+
+		pending := progress.pending(result.name)
+
+		deleteList = [][]types.FunctionConfiguration{pending}
+		result.storage = progress.storage - (progress.plannedSize() - int64(calculateSpaceRemoval(deleteList)))
+	} else {
+		if len(opts.PruneAliases) > 0 {
+			var err error
+
+			pruned, err = pruneAliases(ctx, svc, pool, item, opts, time.Now())
+
+			result.aliasesPruned = len(pruned)
+
+			if err != nil {
+				if ctx.Err() != nil {
+					result.interrupted = true
+
+					return result
+				}
+
+				result.err = fmt.Errorf("failed to prune the aliases of %s: %w", result.name, err)
+
+				return result
+			}
+		}
+
+		// List
+		versions, aliases, err := getAllLambdaVersion(ctx, svc, item, opts, pruned)
+		if err != nil {
+			if ctx.Err() != nil {
+				result.interrupted = true
+
+				return result
+			}
+
+			result.err = fmt.Errorf("failed to retrieve the versions of %s: %w", result.name, err)
+
+			return result
+		}
+
+		result.storage, err = getLambdaStorage(versions)
+		if err != nil {
+			result.err = err
+
+			return result
+		}
+
+		// The rollback windows are excluded after the storage is computed so that the retained versions are included in the storage size.
+		if opts.RetainBehindAlias > 0 {
+			versions = excludeAliasRollback(versions, aliases, opts.RetainBehindAlias)
+		}
+
+		// Plan
+		planned := filterOlderThan(getLambdasToDeleteList(versions, opts.Retain, run.keepVersions...), opts.OlderThan, time.Now())
+
+		if opts.ProtectIntegrations {
+			planned, result.integrations, err = excludeIntegrations(ctx, svc, pool, result.name, planned)
+			if err != nil {
+				if ctx.Err() != nil {
+					result.interrupted = true
+
+					return result
+				}
+
+				result.err = fmt.Errorf("failed to check the integrations of %s: %w", result.name, err)
+
+				return result
+			}
+		}
+
+		// $LATEST cannot be deleted, so it is not recorded as planned. Otherwise, a resumed clean-up would count its size as deleted.
+		planned = slices.DeleteFunc(planned, func(version types.FunctionConfiguration) bool {
+			return *version.Version == "$LATEST"
+		})
+
+		deleteList = [][]types.FunctionConfiguration{planned}
+
+		err = state.planned(result.name, result.storage, deleteList[0])
+		if err != nil {
+			result.err = err
+
+			return result
+		}
+	}
+
+	deleteInputs, err := generateDeleteInputStructs(deleteList, false)
+	if err != nil {
+		result.err = err
+
+		return result
+	}
+
+	result.planned = countDeleteVersions(deleteInputs)
+	result.plannedSize = int64(calculateSpaceRemoval(deleteList))
+
+	if opts.DryRun {
+		return result
+	}
+
+	// Delete
+	if result.planned > 0 {
+		deleted, edgeReplicas, err := deleteLambdaVersion(ctx, svc, pool, state, deleteInputs...)
+
+		result.deleted = len(deleted)
+		result.edgeReplicas = len(edgeReplicas)
+		result.freed = calculateDeletedSpace(deleteList, deleted)
+
+		// No new deletions are started once the context is cancelled. The remaining versions are reported as pending.
+		result.interrupted = ctx.Err() != nil
+
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			result.err = err
+
+			return result
+		}
+
+		if result.interrupted {
+			return result
+		}
+
+		if opts.Verify && !resumed {
+			err = verifyDeletedVersions(ctx, svc, item, deleted, opts)
+			if err != nil {
+				result.err = err
+
+				return result
+			}
+		}
+	}
+
+	err = state.completed(result.name)
+	if err != nil {
+		result.err = err
+	}
+
+	return result
+}
+
+// reportResults consumes the results of the pipeline and reports them in the order of their index.
+// Results that complete out of order are held until all the preceding results are reported. The running totals are returned once the channel is closed.
+func reportResults(results <-chan functionResult, opts Options) Summary {
+	var (
+		summary Summary
+		next    int
+	)
+
+	pending := make(map[int]functionResult)
+
+	for result := range results {
+		pending[result.index] = result
+
+		for {
+			item, ok := pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			next++
+
+			reportResult(item, opts)
+			summary.add(item)
+		}
+	}
+
+	return summary
+}
+
+// reportResult displays the result of a single Lambda.
+func reportResult(result functionResult, opts Options) {
+	if result.err != nil {
+		log.Error("ERROR: ", result.err)
+	}
+
+	if !opts.MoreLambdaDetails || result.planned == 0 {
+		return
+	}
+
+	if opts.DryRun {
+		log.Info(fmt.Sprintf("%5d versions of %s to be removed", result.planned, result.name))
+
+		return
+	}
+
+	log.Info(fmt.Sprintf("%5d versions of %s removed", result.deleted, result.name))
+}
@@ -0,0 +1,60 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReportResults(t *testing.T) {
+
+	opts := Options{
+		DryRun:            false,
+		MoreLambdaDetails: true,
+	}
+
+	results := make(chan functionResult, 3)
+	results <- functionResult{index: 2, name: "C", storage: 300, planned: 1, plannedSize: 100, deleted: 1, freed: 100}
+	results <- functionResult{index: 0, name: "A", storage: 100, planned: 2, plannedSize: 50, deleted: 2, freed: 50}
+	results <- functionResult{index: 1, name: "B", storage: 200, err: errors.New("failed"), interrupted: true}
+	close(results)
+
+	got := reportResults(results, opts)
+
+	want := Summary{
+		Lambdas:     3,
+		Storage:     600,
+		Planned:     3,
+		PlannedSize: 150,
+		Deleted:     3,
+		Freed:       150,
+		Failed:      1,
+		Interrupted: 1,
+	}
+
+	if got != want {
+		t.Fatalf("Expected the summary to be %+v but received %+v", want, got)
+	}
+
+}
+
+func TestReportResultsOutOfOrder(t *testing.T) {
+
+	opts := Options{
+		DryRun:            true,
+		MoreLambdaDetails: false,
+	}
+
+	results := make(chan functionResult, 2)
+	results <- functionResult{index: 1, name: "B", storage: 200}
+	close(results)
+
+	got := reportResults(results, opts)
+
+	if got.Lambdas != 0 {
+		t.Fatalf("Expected results after a missing index to be held back but %d were reported", got.Lambdas)
+	}
+
+}
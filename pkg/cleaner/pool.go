@@ -0,0 +1,96 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultConcurrency is the default number of Lambdas processed at the same time.
+	DefaultConcurrency int = 10
+	// DefaultRateLimit is the default number of operations started per second. It stays below the quota of 15 requests per second of the Lambda control plane API, which is shared by all the clients of the account and region.
+	DefaultRateLimit float64 = 10
+)
+
+// workerPool bounds the number of concurrent AWS API operations and the rate at which they are started.
+// The same pool is shared by the scanning of Lambdas and the deletion of Lambda versions.
+type workerPool struct {
+	concurrency int
+	limiter     *rate.Limiter
+}
+
+// newWorkerPool returns a worker pool that executes at most concurrency operations at the same time.
+// A rateLimit of zero or less disables the rate limit. Otherwise, rateLimit is the maximum number of operations started per second.
+func newWorkerPool(concurrency int, rateLimit float64) *workerPool {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if rateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(rateLimit), 1)
+	}
+
+	return &workerPool{
+		concurrency: concurrency,
+		limiter:     limiter,
+	}
+}
+
+// wait blocks until the rate limit of the pool allows an operation to start or the context is cancelled.
+func (p *workerPool) wait(ctx context.Context) error {
+	return p.limiter.Wait(ctx)
+}
+
+// run calls fn for every index in the range [0, n) and waits for all the calls to complete.
+// No new calls are started once the context is cancelled. The first error encountered is returned.
+func (p *workerPool) run(ctx context.Context, n int, fn func(i int) error) error {
+	var (
+		returnError error
+		mu          sync.Mutex
+		wg          sync.WaitGroup
+	)
+
+	setError := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if returnError == nil {
+			returnError = err
+		}
+	}
+
+	sem := make(chan struct{}, p.concurrency)
+
+	for i := range n {
+		err := p.wait(ctx)
+		if err != nil {
+			setError(err)
+
+			break
+		}
+
+		sem <- struct{}{}
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := fn(i)
+			if err != nil {
+				setError(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return returnError
+}
@@ -0,0 +1,98 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolRun(t *testing.T) {
+
+	var (
+		running    int32
+		maxRunning int32
+		calls      int32
+	)
+
+	pool := newWorkerPool(3, 0)
+
+	err := pool.run(context.Background(), 20, func(i int) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+
+		time.Sleep(time.Millisecond * 5)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&calls, 1)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	if calls != 20 {
+		t.Errorf("expected 20 calls but received %d", calls)
+	}
+
+	if maxRunning > 3 {
+		t.Errorf("expected at most 3 concurrent calls but received %d", maxRunning)
+	}
+
+}
+
+func TestWorkerPoolRunError(t *testing.T) {
+
+	pool := newWorkerPool(0, 0)
+
+	if pool.concurrency != 1 {
+		t.Errorf("expected the concurrency to default to 1 but received %d", pool.concurrency)
+	}
+
+	want := errors.New("failed")
+
+	err := pool.run(context.Background(), 5, func(i int) error {
+		if i == 2 {
+			return want
+		}
+
+		return nil
+	})
+	if !errors.Is(err, want) {
+		t.Errorf("expected error %v to be returned but received %v", want, err)
+	}
+
+}
+
+func TestWorkerPoolRunCancelled(t *testing.T) {
+
+	var calls int32
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	pool := newWorkerPool(2, 1)
+
+	err := pool.run(ctx, 5, func(i int) error {
+		atomic.AddInt32(&calls, 1)
+
+		return nil
+	})
+	if err == nil {
+		t.Errorf("expected an error to be returned but received %v", err)
+	}
+
+	if calls != 0 {
+		t.Errorf("expected no calls to be made but received %d", calls)
+	}
+
+}
@@ -0,0 +1,314 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+const (
+	stateRecordRun       string = "run"
+	stateRecordPlanned   string = "planned"
+	stateRecordDeleted   string = "deleted"
+	stateRecordCompleted string = "completed"
+)
+
+// stateRecord is a single entry of the state file. The state file is a journal with one JSON record per line.
+// A journal is used so that each deletion can be recorded without rewriting the whole file.
+type stateRecord struct {
+	Type     string         `json:"type"`
+	Time     time.Time      `json:"time"`
+	Region   string         `json:"region,omitempty"`
+	Lambdas  []string       `json:"lambdas,omitempty"`
+	Lambda   string         `json:"lambda,omitempty"`
+	Storage  int64          `json:"storage,omitempty"`
+	Versions []stateVersion `json:"versions,omitempty"`
+	Version  string         `json:"version,omitempty"`
+}
+
+// stateVersion is a Lambda version planned for deletion.
+type stateVersion struct {
+	Version string `json:"version"`
+	Size    int64  `json:"size"`
+}
+
+// lambdaProgress is the progress of a single Lambda recorded by a previous execution.
+type lambdaProgress struct {
+	storage   int64
+	planned   []stateVersion
+	deleted   map[string]bool
+	completed bool
+}
+
+// plannedSize returns the total size of the versions planned for deletion by a previous execution.
+func (p lambdaProgress) plannedSize() int64 {
+	var size int64
+
+	for _, version := range p.planned {
+		size = size + version.Size
+	}
+
+	return size
+}
+
+// pending returns the versions that were planned for deletion but not deleted by a previous execution.
+func (p lambdaProgress) pending(name string) []types.FunctionConfiguration {
+	var output []types.FunctionConfiguration
+
+	for _, version := range p.planned {
+		if p.deleted[version.Version] {
+			continue
+		}
+
+		output = append(output, types.FunctionConfiguration{
+			FunctionName: aws.String(name),
+			Version:      aws.String(version.Version),
+			CodeSize:     version.Size,
+		})
+	}
+
+	return output
+}
+
+// checkpoint records the progress of a clean-up in a state file so that an interrupted execution can be resumed.
+// A nil checkpoint is valid and records nothing.
+type checkpoint struct {
+	mu       sync.Mutex
+	file     *os.File
+	region   string
+	lambdas  []string
+	progress map[string]*lambdaProgress
+}
+
+// newCheckpoint creates the state file at the provided path. An existing state file is overwritten.
+func newCheckpoint(path string) (*checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the state file %s: %w", path, err)
+	}
+
+	return &checkpoint{
+		file:     file,
+		progress: make(map[string]*lambdaProgress),
+	}, nil
+}
+
+// resumeCheckpoint loads the progress recorded in the state file at the provided path.
+// New progress is appended to the same state file.
+func resumeCheckpoint(path string) (*checkpoint, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open the state file %s: %w", path, err)
+	}
+
+	c := &checkpoint{
+		file:     file,
+		progress: make(map[string]*lambdaProgress),
+	}
+
+	err = c.load()
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("unable to resume from the state file %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// load replays the records of the state file.
+// A malformed last record is ignored, as it is the result of an execution that stopped while the record was written.
+func (c *checkpoint) load() error {
+	var malformed error
+
+	scanner := bufio.NewScanner(c.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	for scanner.Scan() {
+		if malformed != nil {
+			return malformed
+		}
+
+		var record stateRecord
+
+		err := json.Unmarshal(scanner.Bytes(), &record)
+		if err != nil {
+			malformed = errors.New("the state file contains a malformed record")
+
+			continue
+		}
+
+		c.apply(record)
+	}
+
+	err := scanner.Err()
+	if err != nil {
+		return err
+	}
+
+	if c.lambdas == nil {
+		return errors.New("the state file does not contain a clean-up execution")
+	}
+
+	return nil
+}
+
+// apply updates the recorded progress with a single record.
+func (c *checkpoint) apply(record stateRecord) {
+	switch record.Type {
+	case stateRecordRun:
+		c.region = record.Region
+		c.lambdas = record.Lambdas
+
+	case stateRecordPlanned:
+		c.progress[record.Lambda] = &lambdaProgress{
+			storage: record.Storage,
+			planned: record.Versions,
+			deleted: make(map[string]bool),
+		}
+
+	case stateRecordDeleted:
+		if progress, ok := c.progress[record.Lambda]; ok {
+			progress.deleted[record.Version] = true
+		}
+
+	case stateRecordCompleted:
+		if progress, ok := c.progress[record.Lambda]; ok {
+			progress.completed = true
+		}
+	}
+}
+
+// lambdaProgress returns the progress recorded for a Lambda by a previous execution.
+// The boolean is false if the Lambda was not planned by a previous execution.
+func (c *checkpoint) lambdaProgress(name string) (lambdaProgress, bool) {
+	if c == nil {
+		return lambdaProgress{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	progress, ok := c.progress[name]
+	if !ok {
+		return lambdaProgress{}, false
+	}
+
+	return *progress, true
+}
+
+// pendingVersion returns true if a previous execution planned the deletion of the version but did not record it as deleted.
+// The records written by the current execution are not included.
+func (c *checkpoint) pendingVersion(name, version string) bool {
+	if c == nil {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	progress, ok := c.progress[name]
+	if !ok || progress.deleted[version] {
+		return false
+	}
+
+	for _, planned := range progress.planned {
+		if planned.Version == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resumed returns true if the checkpoint contains the progress of a previous execution.
+func (c *checkpoint) resumed() bool {
+	return c != nil && c.lambdas != nil
+}
+
+// start records the region and the Lambdas targeted by the clean-up.
+func (c *checkpoint) start(region string, lambdas []string) error {
+	return c.write(stateRecord{
+		Type:    stateRecordRun,
+		Region:  region,
+		Lambdas: lambdas,
+	})
+}
+
+// planned records the versions of a Lambda planned for deletion.
+func (c *checkpoint) planned(name string, storage int64, versions []types.FunctionConfiguration) error {
+	record := stateRecord{
+		Type:    stateRecordPlanned,
+		Lambda:  name,
+		Storage: storage,
+	}
+
+	for _, version := range versions {
+		record.Versions = append(record.Versions, stateVersion{
+			Version: *version.Version,
+			Size:    version.CodeSize,
+		})
+	}
+
+	return c.write(record)
+}
+
+// deleted records the deletion of a Lambda version.
+func (c *checkpoint) deleted(name string, version string) error {
+	return c.write(stateRecord{
+		Type:    stateRecordDeleted,
+		Lambda:  name,
+		Version: version,
+	})
+}
+
+// completed records that all the planned versions of a Lambda are deleted.
+func (c *checkpoint) completed(name string) error {
+	return c.write(stateRecord{
+		Type:   stateRecordCompleted,
+		Lambda: name,
+	})
+}
+
+// write appends a record to the state file.
+func (c *checkpoint) write(record stateRecord) error {
+	if c == nil {
+		return nil
+	}
+
+	record.Time = time.Now().UTC()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err = c.file.Write(append(data, '\n'))
+	if err != nil {
+		return fmt.Errorf("unable to update the state file: %w", err)
+	}
+
+	return nil
+}
+
+// close closes the state file.
+func (c *checkpoint) close() error {
+	if c == nil {
+		return nil
+	}
+
+	return c.file.Close()
+}
@@ -0,0 +1,139 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+func TestCheckpointResume(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := newCheckpoint(path)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+
+	versions := []types.FunctionConfiguration{
+		{Version: aws.String("1"), CodeSize: 100},
+		{Version: aws.String("2"), CodeSize: 200},
+		{Version: aws.String("3"), CodeSize: 300},
+	}
+
+	steps := []error{
+		state.start("us-east-1", []string{"A", "B", "C"}),
+		state.planned("A", 1000, versions),
+		state.deleted("A", "1"),
+		state.deleted("A", "2"),
+		state.planned("B", 500, versions[:1]),
+		state.deleted("B", "1"),
+		state.completed("B"),
+		state.close(),
+	}
+
+	for _, err := range steps {
+		if err != nil {
+			t.Fatalf("expected no error to be returned but received %v", err)
+		}
+	}
+
+	resumed, err := resumeCheckpoint(path)
+	if err != nil {
+		t.Fatalf("expected no error to be returned but received %v", err)
+	}
+	defer resumed.close()
+
+	if !resumed.resumed() || resumed.region != "us-east-1" || len(resumed.lambdas) != 3 {
+		t.Fatalf("expected the execution to be restored from the state file but received %v %v", resumed.region, resumed.lambdas)
+	}
+
+	progress, ok := resumed.lambdaProgress("A")
+	if !ok || progress.completed {
+		t.Fatalf("expected lambda A to be planned and not completed")
+	}
+
+	pending := progress.pending("A")
+	if len(pending) != 1 || *pending[0].Version != "3" || *pending[0].FunctionName != "A" {
+		t.Fatalf("expected version 3 of lambda A to be pending but received %v", pending)
+	}
+
+	if progress.plannedSize() != 600 {
+		t.Errorf("expected the planned size to be 600 but received %d", progress.plannedSize())
+	}
+
+	progress, ok = resumed.lambdaProgress("B")
+	if !ok || !progress.completed {
+		t.Errorf("expected lambda B to be completed")
+	}
+
+	_, ok = resumed.lambdaProgress("C")
+	if ok {
+		t.Errorf("expected lambda C to not be planned")
+	}
+
+}
+
+func TestCheckpointResumeMalformed(t *testing.T) {
+
+	dir := t.TempDir()
+
+	truncated := filepath.Join(dir, "truncated.json")
+	content := `{"type":"run","region":"us-east-1","lambdas":["A"]}` + "\n" + `{"type":"planned","lambda":"A","vers`
+
+	err := os.WriteFile(truncated, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := resumeCheckpoint(truncated)
+	if err != nil {
+		t.Fatalf("expected a truncated last record to be ignored but received %v", err)
+	}
+	state.close()
+
+	malformed := filepath.Join(dir, "malformed.json")
+	content = `{"type":"run","region":"us-east-1","lambdas":["A"]}` + "\n" + `not json` + "\n" + `{"type":"completed","lambda":"A"}` + "\n"
+
+	err = os.WriteFile(malformed, []byte(content), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = resumeCheckpoint(malformed)
+	if err == nil {
+		t.Errorf("expected an error to be returned for a malformed record but received %v", err)
+	}
+
+	_, err = resumeCheckpoint(filepath.Join(dir, "missing.json"))
+	if err == nil {
+		t.Errorf("expected an error to be returned for a missing state file but received %v", err)
+	}
+
+}
+
+func TestCheckpointNil(t *testing.T) {
+
+	var state *checkpoint
+
+	if state.resumed() {
+		t.Errorf("expected a nil checkpoint to not be resumed")
+	}
+
+	err := state.deleted("A", "1")
+	if err != nil {
+		t.Errorf("expected no error to be returned but received %v", err)
+	}
+
+	_, ok := state.lambdaProgress("A")
+	if ok {
+		t.Errorf("expected a nil checkpoint to not contain progress")
+	}
+
+}
@@ -0,0 +1,173 @@
+// Copyright (c) karl-cardenas-coding
+// SPDX-License-Identifier: MIT
+
+package cleaner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	internal "github.com/karl-cardenas-coding/go-lambda-cleanup/v2/internal"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// retainTag is the tag of a Lambda that sets the number of versions to retain.
+	retainTag string = "glc:retain"
+	// skipTag is the tag of a Lambda that excludes it from the clean-up when set to true.
+	skipTag string = "glc:skip"
+	// maxAgeTag is the tag of a Lambda that limits the deletion to the versions older than the age, such as 14d.
+	maxAgeTag string = "glc:max-age"
+)
+
+/*
+applyTagOverrides reads the glc: tags of each Lambda and returns the Lambdas that are not skipped along with the overrides that include the settings of the tags.
+The settings of the overrides provided take precedence over the tags, which take precedence over the Options.
+The tags are read by the worker pool. A Lambda with an invalid tag value is skipped so that a typo never deletes more versions than its owner intended.
+An error is returned if the tags of a Lambda cannot be read.
+*/
+func applyTagOverrides(ctx context.Context, svc LambdaAPI, pool *workerPool, lambdaList []types.FunctionConfiguration, overrides map[string]Override) ([]types.FunctionConfiguration, map[string]Override, error) {
+	var output []types.FunctionConfiguration
+
+	merged := make(map[string]Override, len(overrides))
+	for name, override := range overrides {
+		merged[name] = override
+	}
+
+	tags := make([]map[string]string, len(lambdaList))
+
+	err := pool.run(ctx, len(lambdaList), func(i int) error {
+		var err error
+
+		tags[i], err = lambdaTags(ctx, svc, lambdaList[i])
+		if err != nil {
+			return fmt.Errorf("failed to retrieve the tags of %s: %w", aws.ToString(lambdaList[i].FunctionName), err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The tags are applied in the order of the list so that the output is deterministic.
+	for i, item := range lambdaList {
+		name := aws.ToString(item.FunctionName)
+
+		override, skip, err := tagOverride(tags[i])
+		if err != nil {
+			log.Warn(fmt.Sprintf("Skipping %s as its tags are invalid: %v", name, err))
+
+			continue
+		}
+
+		if skip {
+			log.Info("Skipping " + name + " as it is tagged " + skipTag + "=true")
+
+			continue
+		}
+
+		merged[name] = mergeTagOverride(name, merged[name], override, tags[i])
+
+		output = append(output, item)
+	}
+
+	return output, merged, nil
+}
+
+// lambdaTags returns the tags of a Lambda. The ListTags operation requires the ARN of the Lambda, so the tags of a Lambda resumed from a state file,
+// which is only identified by its name, are read with GetFunction instead. A resumed Lambda that no longer exists has no tags.
+func lambdaTags(ctx context.Context, svc LambdaAPI, item types.FunctionConfiguration) (map[string]string, error) {
+	if item.FunctionArn != nil {
+		result, err := svc.ListTags(ctx, &lambda.ListTagsInput{
+			Resource: item.FunctionArn,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return result.Tags, nil
+	}
+
+	result, err := svc.GetFunction(ctx, &lambda.GetFunctionInput{
+		FunctionName: item.FunctionName,
+	})
+	if err != nil {
+		var rnf *types.ResourceNotFoundException
+		if errors.As(err, &rnf) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return result.Tags, nil
+}
+
+// tagOverride returns the override set by the glc: tags of a Lambda and whether the Lambda is skipped. An error is returned if a tag value is invalid.
+func tagOverride(tags map[string]string) (Override, bool, error) {
+	var override Override
+
+	if value, ok := tags[skipTag]; ok {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return override, false, fmt.Errorf("%s must be true or false but received %q", skipTag, value)
+		}
+
+		if skip {
+			return override, true, nil
+		}
+	}
+
+	if value, ok := tags[retainTag]; ok {
+		retain, err := strconv.ParseInt(value, 10, 8)
+		if err != nil || retain < 0 {
+			return override, false, fmt.Errorf("%s must be an integer between 0 and 127 but received %q", retainTag, value)
+		}
+
+		retainValue := int8(retain)
+		override.Retain = &retainValue
+	}
+
+	if value, ok := tags[maxAgeTag]; ok {
+		olderThan, err := internal.ParseOlderThan(value)
+		if err != nil {
+			return override, false, fmt.Errorf("%s is invalid: %w", maxAgeTag, err)
+		}
+
+		override.OlderThan = olderThan
+	}
+
+	return override, false, nil
+}
+
+// mergeTagOverride returns the override of a Lambda with the settings of its tags applied where the override does not set them.
+// The tag that drove each setting is reported so that a dry run shows why the Lambda is cleaned differently.
+func mergeTagOverride(name string, override, tagged Override, tags map[string]string) Override {
+	if tagged.Retain != nil {
+		if override.Retain == nil {
+			override.Retain = tagged.Retain
+
+			log.Info(fmt.Sprintf("Retaining %d versions of %s as it is tagged %s=%s", *tagged.Retain, name, retainTag, tags[retainTag]))
+		} else {
+			log.Debug(fmt.Sprintf("Ignoring the tag %s of %s as the custom list sets the retain value", retainTag, name))
+		}
+	}
+
+	if tagged.OlderThan != 0 {
+		if override.OlderThan == 0 {
+			override.OlderThan = tagged.OlderThan
+
+			log.Info(fmt.Sprintf("Limiting the deletion of %s to the versions older than %s as it is tagged %s=%s", name, tags[maxAgeTag], maxAgeTag, tags[maxAgeTag]))
+		} else {
+			log.Debug(fmt.Sprintf("Ignoring the tag %s of %s as the custom list sets the olderThan value", maxAgeTag, name))
+		}
+	}
+
+	return override
+}